@@ -0,0 +1,229 @@
+package v8tsgo
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"rogchap.com/v8go"
+)
+
+var (
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	valueType   = reflect.TypeOf((*v8go.Value)(nil))
+)
+
+// funcBinding calls a go func from js
+type funcBinding struct {
+	rt   *Runtime
+	name string
+	fn   reflect.Value
+	// the func takes a context.Context first and returns a promise
+	async bool
+	// the types of the params receiving the js arguments
+	params    []reflect.Type
+	variadic  bool
+	hasResult bool
+	hasError  bool
+}
+
+func newFuncBinding(rt *Runtime, name string, fn reflect.Value) (*funcBinding, error) {
+	if !fn.IsValid() || fn.Kind() != reflect.Func || fn.IsNil() {
+		return nil, fmt.Errorf("unable to bind %s, expect a non-nil func, but got %v", name, fn)
+	}
+	t := fn.Type()
+	b := &funcBinding{
+		rt:       rt,
+		name:     name,
+		fn:       fn,
+		variadic: t.IsVariadic(),
+	}
+	for i := 0; i < t.NumIn(); i++ {
+		in := t.In(i)
+		if i == 0 && in == contextType {
+			b.async = true
+			continue
+		}
+		b.params = append(b.params, in)
+	}
+	if b.async {
+		for _, in := range b.params {
+			if in == valueType || b.variadic && in.Elem() == valueType {
+				return nil, fmt.Errorf("unable to bind %s, an async func can't take *v8go.Value, it runs on another goroutine", name)
+			}
+		}
+	}
+	switch t.NumOut() {
+	case 0:
+	case 1:
+		b.hasError = t.Out(0) == errorType
+		b.hasResult = !b.hasError
+	case 2:
+		if t.Out(1) != errorType {
+			return nil, fmt.Errorf("unable to bind %s, the second result must be an error, but got %s", name, t.Out(1))
+		}
+		b.hasResult = true
+		b.hasError = true
+	default:
+		return nil, fmt.Errorf("unable to bind %s, expect at most a result and an error, but got %d results", name, t.NumOut())
+	}
+	return b, nil
+}
+
+func (b *funcBinding) argumentError(i int, err error) error {
+	return &JSException{
+		Name:    "TypeError",
+		Message: fmt.Sprintf("invalid argument %d of %s, %s", i, b.name, err),
+		Cause:   err,
+	}
+}
+
+// args decodes the js arguments, the missing ones are decoded from undefined to the zero values.
+func (b *funcBinding) args(ctx *v8go.Context, jsArgs []*v8go.Value) ([]reflect.Value, error) {
+	fixed := len(b.params)
+	if b.variadic {
+		fixed--
+	}
+	d := &decoder{ctx: ctx}
+	undefined := v8go.Undefined(ctx.Isolate())
+	decodeArg := func(i int, t reflect.Type) (reflect.Value, error) {
+		value := undefined
+		if i < len(jsArgs) {
+			value = jsArgs[i]
+		}
+		arg := reflect.New(t).Elem()
+		if t == valueType {
+			arg.Set(reflect.ValueOf(value))
+			return arg, nil
+		}
+		err := d.decode(value, arg, "")
+		if err != nil {
+			return arg, b.argumentError(i, err)
+		}
+		return arg, nil
+	}
+	args := make([]reflect.Value, 0, len(b.params)+1)
+	for i := 0; i < fixed; i++ {
+		arg, err := decodeArg(i, b.params[i])
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	if b.variadic {
+		elem := b.params[fixed].Elem()
+		for i := fixed; i < len(jsArgs); i++ {
+			arg, err := decodeArg(i, elem)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+	}
+	return args, nil
+}
+
+// call calls the func, a panic is returned as an error, so it never unwinds through v8
+func (b *funcBinding) call(args []reflect.Value) (result any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%s panicked, %v", b.name, r)
+		}
+	}()
+	outs := b.fn.Call(args)
+	if b.hasError {
+		if errOut := outs[len(outs)-1]; !errOut.IsNil() {
+			return nil, errOut.Interface().(error)
+		}
+	}
+	if b.hasResult {
+		return outs[0].Interface(), nil
+	}
+	return nil, nil
+}
+
+func (b *funcBinding) makeResult(ctx *v8go.Context, result any) (*v8go.Value, error) {
+	if !b.hasResult {
+		return v8go.Undefined(ctx.Isolate()), nil
+	}
+	value, err := MakeValue(ctx, result)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make the result value of %s, %w", b.name, err)
+	}
+	return value, nil
+}
+
+func (b *funcBinding) callback(info *v8go.FunctionCallbackInfo) *v8go.Value {
+	return b.invoke(info.Context(), info.Args())
+}
+
+// invoke calls the func with the js arguments and returns its js result, or a promise of it if the func is async
+func (b *funcBinding) invoke(ctx *v8go.Context, jsArgs []*v8go.Value) *v8go.Value {
+	iso := ctx.Isolate()
+	utils := b.rt.utils
+	args, err := b.args(ctx, jsArgs)
+	if !b.async {
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		result, err := b.call(args)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		value, err := b.makeResult(ctx, result)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		return value
+	}
+	resolver := mustMakeResolver(ctx)
+	if err != nil {
+		resolver.Reject(mustWrapError(utils, err))
+		return resolver.GetPromise().Value
+	}
+	args = append([]reflect.Value{reflect.ValueOf(utils.loop.Context())}, args...)
+	utils.loop.Go(func() func() {
+		result, err := b.call(args)
+		return func() {
+			if err != nil {
+				resolver.Reject(mustWrapError(utils, err))
+				return
+			}
+			value, err := b.makeResult(ctx, result)
+			if err != nil {
+				resolver.Reject(mustWrapError(utils, err))
+			} else {
+				resolver.Resolve(value)
+			}
+		}
+	})
+	return resolver.GetPromise().Value
+}
+
+// NewFuncTemplate creates a function template calling fn, see BindFunc.
+func NewFuncTemplate(rt *Runtime, name string, fn any) (*v8go.FunctionTemplate, error) {
+	b, err := newFuncBinding(rt, name, reflect.ValueOf(fn))
+	if err != nil {
+		return nil, err
+	}
+	return v8go.NewFunctionTemplate(rt.ctx.Isolate(), b.callback), nil
+}
+
+// BindFunc sets the global function name calling fn. The js arguments are decoded by ParseValue into
+// the params of fn, the missing ones decode from undefined, and the params of type *v8go.Value receive
+// the arguments as is. The result is converted by MakeValue and a non-nil trailing error is thrown.
+// If the first param of fn is a context.Context, the function returns a promise and fn runs on another
+// goroutine, the promise settles on the loop of the runtime. The context is the one of Loop.Context,
+// it is cancelled when the loop is closed.
+func BindFunc(rt *Runtime, name string, fn any) error {
+	tmpl, err := NewFuncTemplate(rt, name, fn)
+	if err != nil {
+		return err
+	}
+	err = rt.ctx.Global().Set(name, tmpl.GetFunction(rt.ctx))
+	if err != nil {
+		return fmt.Errorf("unable to set the global function %s, %w", name, err)
+	}
+	return nil
+}
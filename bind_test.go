@@ -0,0 +1,113 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+	"testing"
+	"time"
+
+	"rogchap.com/v8go"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+type testPoint struct {
+	X int `json:"x"`
+	Y int `json:"y"`
+}
+
+func TestBindFunc(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	panicIfErr(BindFunc(rt, "add", func(a, b int) int { return a + b }))
+	panicIfErr(BindFunc(rt, "join", func(sep string, parts ...string) string { return strings.Join(parts, sep) }))
+	panicIfErr(BindFunc(rt, "move", func(p testPoint, dx int) testPoint { return testPoint{p.X + dx, p.Y} }))
+	panicIfErr(BindFunc(rt, "open", func(path string) error {
+		return &fs.PathError{Op: "open", Path: path, Err: fs.ErrNotExist}
+	}))
+	panicIfErr(BindFunc(rt, "typeOf", func(v *v8go.Value) string { return v.DetailString() }))
+	panicIfErr(BindFunc(rt, "boom", func() { panic("boom") }))
+	res, err := ctx.RunScript(`
+		function describe(fn) {
+			try {
+				return String(fn());
+			} catch (e) {
+				return e.name + ':' + e.code + ':' + e.message;
+			}
+		}
+		[
+			add(1, 2), add(1), join('-', 'a', 'b', 'c'), JSON.stringify(move({ x: 1, y: 2 }, 3)),
+			describe(() => open('/a.txt')), describe(() => add('x', 1)), typeOf(undefined),
+			describe(() => boom()),
+		].join('\n')
+	`, "bind.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "3\n1\na-b-c\n"+`{"x":4,"y":2}`+"\n"+
+		"Error:ENOENT:open /a.txt: file does not exist\n"+
+		"TypeError:undefined:invalid argument 0 of add, expected number\n"+
+		"undefined\n"+
+		"Error:undefined:boom panicked, boom", res.String(), "")
+}
+
+func TestBindFuncAsync(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	panicIfErr(BindFunc(rt, "sleep", func(ctx context.Context, ms int) (string, error) {
+		if ms < 0 {
+			return "", fmt.Errorf("negative duration %d", ms)
+		}
+		time.Sleep(time.Duration(ms) * time.Millisecond)
+		return fmt.Sprintf("slept %dms", ms), nil
+	}))
+	promise, err := ctx.RunScript("sleep(5)", "async.js")
+	panicIfErr(err)
+	test.MustEqual(t, true, promise.IsPromise(), "")
+	v, err := rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, "slept 5ms", v.String(), "")
+
+	promise, err = ctx.RunScript("sleep(-1)", "async.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	var ex *JSException
+	test.MustEqual(t, true, errors.As(err, &ex), "")
+	test.AssertEqual(t, "negative duration -1", ex.Message, "")
+
+	err = BindFunc(rt, "bad", func(ctx context.Context, v *v8go.Value) {})
+	test.AssertEqual(t, true, err != nil, "")
+	err = BindFunc(rt, "bad", func() (int, int) { return 0, 0 })
+	test.AssertEqual(t, true, err != nil, "")
+	err = BindFunc(rt, "bad", 1)
+	test.AssertEqual(t, true, err != nil, "")
+}
+
+func TestBindFuncAsyncCancel(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+	cancelled := make(chan error, 1)
+	panicIfErr(BindFunc(rt, "wait", func(ctx context.Context) error {
+		<-ctx.Done()
+		cancelled <- ctx.Err()
+		return ctx.Err()
+	}))
+
+	// the caller awaiting the promise giving up leaves the work running
+	promise, err := ctx.RunScript("wait()", "cancel.js")
+	panicIfErr(err)
+	awaitCtx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = rt.Await(awaitCtx, promise)
+	test.AssertEqual(t, true, errors.Is(err, context.DeadlineExceeded), "")
+	test.AssertEqual(t, nil, rt.Loop().Context().Err(), "")
+	test.AssertEqual(t, 0, len(cancelled), "")
+
+	// closing the loop cancels it
+	rt.Loop().Close()
+	test.AssertEqual(t, context.Canceled, <-cancelled, "")
+	test.AssertEqual(t, context.Canceled, rt.Loop().Context().Err(), "")
+}
@@ -0,0 +1,120 @@
+package v8tsgo
+
+import (
+	"fmt"
+
+	v8 "rogchap.com/v8go"
+)
+
+// BuildResult is the result of a Builder build.
+type BuildResult struct {
+	EmitResult
+	// AffectedFiles are the files checked by this build, the results of the other files are reused.
+	AffectedFiles []string
+}
+
+func decodeBuildResult(value *v8.Value) (*BuildResult, error) {
+	emitResult, err := decodeEmitResult(value)
+	if err != nil {
+		return nil, err
+	}
+	out := &BuildResult{EmitResult: *emitResult}
+	obj, err := value.AsObject()
+	if err != nil {
+		return nil, err
+	}
+	valFiles, err := objectGet(obj, "affectedFiles")
+	if err != nil {
+		return nil, err
+	}
+	files, err := arrayElements(valFiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the affected files, %w", err)
+	}
+	for _, file := range files {
+		out.AffectedFiles = append(out.AffectedFiles, file.String())
+	}
+	return out, nil
+}
+
+// Builder keeps an incremental typescript program alive between the builds, each build only checks
+// and emits the files affected by the changes. The incremental option is enabled unless it is set,
+// and the state is written to the .tsbuildinfo file through the file system of the compiler, so a new
+// Builder starts from the state of the previous one. Typescript only derives the path of the
+// .tsbuildinfo file from the tsBuildInfoFile, outFile or configFilePath options, without them the
+// state only lives as long as the Builder.
+// A Builder shares the isolate of its Compiler and is not safe for concurrent use.
+type Builder struct {
+	c       *Compiler
+	builder *v8.Object
+}
+
+// NewBuilder creates a Builder of the root files, nothing is checked until the first build.
+func (c *Compiler) NewBuilder(rootNames []string, options map[string]any) (*Builder, error) {
+	return c.newBuilder(rootNames, options, nil)
+}
+
+// newBuilder creates a Builder whose imports of the referenced projects resolve to their outputs
+func (c *Compiler) newBuilder(rootNames []string, options map[string]any, references []ProjectReference) (*Builder, error) {
+	valRootNames, valOptions, err := c.makeArgs(rootNames, options)
+	if err != nil {
+		return nil, err
+	}
+	if references == nil {
+		references = []ProjectReference{}
+	}
+	valReferences, err := MakeValue(c.ctx, references)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make the project references value, %w", err)
+	}
+	res, err := c.api.MethodCall("createBuilder", c.host, c.bundle, valRootNames, valOptions, valReferences)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the builder, %w", AsJSException(err))
+	}
+	builder, err := res.AsObject()
+	if err != nil {
+		return nil, fmt.Errorf("unable to cast the builder to an object, %w", err)
+	}
+	return &Builder{
+		c:       c,
+		builder: builder,
+	}, nil
+}
+
+// Build checks and emits the program. changedPaths are the files changed since the previous build,
+// the builder caches the other files, so a change not reported here is not seen. The first build
+// reads all the files and needs no changed paths.
+func (b *Builder) Build(changedPaths []string) (*BuildResult, error) {
+	if changedPaths == nil {
+		changedPaths = []string{}
+	}
+	valChangedPaths, err := MakeValue(b.c.ctx, changedPaths)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make the changed paths value, %w", err)
+	}
+	res, err := b.builder.MethodCall("build", valChangedPaths)
+	if err != nil {
+		return nil, fmt.Errorf("unable to build, %w", AsJSException(err))
+	}
+	out, err := decodeBuildResult(res)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the build result, %w", err)
+	}
+	return out, nil
+}
+
+// SetRootNames replaces the root files from the next build on.
+func (b *Builder) SetRootNames(rootNames []string) error {
+	if rootNames == nil {
+		rootNames = []string{}
+	}
+	valRootNames, err := MakeValue(b.c.ctx, rootNames)
+	if err != nil {
+		return fmt.Errorf("unable to make the root names value, %w", err)
+	}
+	_, err = b.builder.MethodCall("setRootNames", valRootNames)
+	if err != nil {
+		return fmt.Errorf("unable to set the root names, %w", AsJSException(err))
+	}
+	return nil
+}
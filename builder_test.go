@@ -0,0 +1,80 @@
+package v8tsgo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestBuilder(t *testing.T) {
+	c, mfs := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "export const a: number = 1;",
+		"/src/b.ts": "// @error bad thing",
+	})
+	rootNames := []string{"/src/a.ts", "/src/b.ts"}
+	options := map[string]any{"outDir": "/dist", "tsBuildInfoFile": "/build/.tsbuildinfo"}
+	b, err := c.NewBuilder(rootNames, options)
+	panicIfErr(err)
+
+	res, err := b.Build(nil)
+	panicIfErr(err)
+	test.AssertEqual(t, "/src/a.ts,/src/b.ts", strings.Join(res.AffectedFiles, ","), "")
+	test.AssertEqual(t, "/dist/a.js,/dist/b.js,/build/.tsbuildinfo", strings.Join(res.EmittedFiles, ","), "")
+	test.MustEqual(t, 1, len(res.Diagnostics), "")
+	test.AssertEqual(t, "/src/b.ts", res.Diagnostics[0].File, "")
+
+	panicIfErr(mfs.WriteFile("/src/a.ts", "export const a: number = 2;"))
+	res, err = b.Build([]string{"/src/a.ts"})
+	panicIfErr(err)
+	test.AssertEqual(t, "/src/a.ts", strings.Join(res.AffectedFiles, ","), "")
+	test.AssertEqual(t, "/dist/a.js,/build/.tsbuildinfo", strings.Join(res.EmittedFiles, ","), "")
+	// the diagnostics of the unaffected files are reused
+	test.MustEqual(t, 1, len(res.Diagnostics), "")
+	test.AssertEqual(t, "bad thing", res.Diagnostics[0].Message, "")
+	content, err := mfs.ReadFile("/dist/a.js", "utf-8")
+	panicIfErr(err)
+	test.AssertEqual(t, "export const a = 2;", content, "")
+
+	// the changes not reported are not seen
+	panicIfErr(mfs.WriteFile("/src/b.ts", "export {};"))
+	res, err = b.Build(nil)
+	panicIfErr(err)
+	test.AssertEqual(t, 0, len(res.AffectedFiles), "")
+	test.AssertEqual(t, 1, len(res.Diagnostics), "")
+
+	panicIfErr(mfs.WriteFile("/src/c.ts", "export const c = 3;"))
+	panicIfErr(b.SetRootNames(append(rootNames, "/src/c.ts")))
+	res, err = b.Build([]string{"/src/b.ts"})
+	panicIfErr(err)
+	test.AssertEqual(t, "/src/b.ts,/src/c.ts", strings.Join(res.AffectedFiles, ","), "")
+	test.AssertEqual(t, 0, len(res.Diagnostics), "")
+}
+
+func TestBuilderRestart(t *testing.T) {
+	c, mfs := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "export const a: number = 1;",
+		"/src/b.ts": "// @error bad thing",
+	})
+	rootNames := []string{"/src/a.ts", "/src/b.ts"}
+	options := map[string]any{"outDir": "/dist", "tsBuildInfoFile": "/dist/.tsbuildinfo"}
+	b, err := c.NewBuilder(rootNames, options)
+	panicIfErr(err)
+	_, err = b.Build(nil)
+	panicIfErr(err)
+
+	// a new compiler over the same files starts from the state in .tsbuildinfo
+	panicIfErr(mfs.WriteFile("/src/a.ts", "export const a: number = 2;"))
+	c2, err := NewCompiler(mfs, WithBundle(newTestBundle(t)))
+	panicIfErr(err)
+	t.Cleanup(c2.Close)
+	b, err = c2.NewBuilder(rootNames, options)
+	panicIfErr(err)
+	res, err := b.Build(nil)
+	panicIfErr(err)
+	test.AssertEqual(t, "/src/a.ts", strings.Join(res.AffectedFiles, ","), "")
+	test.AssertEqual(t, "/dist/a.js,/dist/.tsbuildinfo", strings.Join(res.EmittedFiles, ","), "")
+	test.MustEqual(t, 1, len(res.Diagnostics), "")
+	test.AssertEqual(t, "/src/b.ts", res.Diagnostics[0].File, "")
+	test.AssertEqual(t, 3, res.Diagnostics[0].Start, "")
+}
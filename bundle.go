@@ -0,0 +1,80 @@
+package v8tsgo
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"strings"
+)
+
+// The content of js/dist is produced by `pnpm run build` in the js directory. Only bootstrap.js is
+// committed, typescript.js, the lib files and VERSION must be built before this package is compiled,
+// the users of a module copy without them pass their own build of js/dist with WithBundle.
+//
+//go:embed js/dist
+var embeddedBundle embed.FS
+
+// ErrBundleNotBuilt is returned when the bundle misses typescript.js or bootstrap.js.
+var ErrBundleNotBuilt = errors.New("the typescript bundle is not built, run `pnpm run build` in the js directory")
+
+const (
+	bundleTypescript = "typescript.js"
+	bundleBootstrap  = "bootstrap.js"
+	bundleLibDir     = "lib"
+	// the virtual directory the compiler host serves the embedded lib files from
+	bundleLibMount = "/__v8tsgo__/lib"
+)
+
+// DefaultBundle returns the bundle embedded in this package.
+func DefaultBundle() fs.FS {
+	bundle, err := fs.Sub(embeddedBundle, "js/dist")
+	if err != nil {
+		panic(err)
+	}
+	return bundle
+}
+
+func readBundleFile(bundle fs.FS, name string) (string, error) {
+	content, err := fs.ReadFile(bundle, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return "", fmt.Errorf("%w, missing %s", ErrBundleNotBuilt, name)
+		}
+		return "", fmt.Errorf("unable to read %s from the bundle, %w", name, err)
+	}
+	return string(content), nil
+}
+
+type bundleScript struct {
+	name   string
+	source string
+}
+
+// readBundleScripts returns the scripts every context evaluates, in order.
+func readBundleScripts(bundle fs.FS) ([]bundleScript, error) {
+	tsSource, err := readBundleFile(bundle, bundleTypescript)
+	if err != nil {
+		return nil, err
+	}
+	bootstrapSource, err := readBundleFile(bundle, bundleBootstrap)
+	if err != nil {
+		return nil, err
+	}
+	return []bundleScript{
+		{name: bundleTypescript, source: tsSource},
+		{name: bundleBootstrap, source: bootstrapSource},
+		{name: goUtilsOrigin, source: goUtilsScript},
+	}, nil
+}
+
+func readBundleLib(bundle fs.FS, name string) (string, bool) {
+	if name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	content, err := fs.ReadFile(bundle, bundleLibDir+"/"+name)
+	if err != nil {
+		return "", false
+	}
+	return string(content), true
+}
@@ -0,0 +1,364 @@
+package v8tsgo
+
+import (
+	"fmt"
+	"reflect"
+	"unicode"
+
+	"rogchap.com/v8go"
+)
+
+// jsName converts a go method name to camel case, "ReadFile" to "readFile" and "URLPath" to "urlPath".
+func jsName(name string) string {
+	runes := []rune(name)
+	n := 0
+	for n < len(runes) && unicode.IsUpper(runes[n]) {
+		n++
+	}
+	if n > 1 && n < len(runes) {
+		// the last capital starts the next word
+		n--
+	}
+	for i := 0; i < n; i++ {
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	return string(runes)
+}
+
+// classBinding exposes a struct type as a js class. The instances are registered by id, so the js objects
+// only keep the id and their fields and methods call back into go with it.
+type classBinding struct {
+	rt   *Runtime
+	name string
+	typ  reflect.Type
+	// the fields keyed by their json names
+	fields map[string]structField
+	// the methods keyed by their js names, they are checked on a zero value and called on the instances
+	methods     map[string]*funcBinding
+	methodIndex map[string]int
+	ctor        *funcBinding
+
+	instances map[int32]reflect.Value
+	ids       map[any]int32
+	nextID    int32
+
+	class    *v8go.Value
+	fnWrap   *v8go.Function
+	fnUnwrap *v8go.Function
+}
+
+func newClassBinding(rt *Runtime, name string, typ reflect.Type, ctor any) (*classBinding, error) {
+	if typ.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("unable to bind the class %s, expect a struct type, but got %s", name, typ)
+	}
+	c := &classBinding{
+		rt:          rt,
+		name:        name,
+		typ:         typ,
+		fields:      map[string]structField{},
+		methods:     map[string]*funcBinding{},
+		methodIndex: map[string]int{},
+		instances:   map[int32]reflect.Value{},
+		ids:         map[any]int32{},
+	}
+	fieldNames, methodNames := []string{}, []string{}
+	for _, f := range structFields(typ) {
+		c.fields[f.name] = f
+		fieldNames = append(fieldNames, f.name)
+	}
+	ptr := reflect.PointerTo(typ)
+	zero := reflect.New(typ)
+	for i := 0; i < ptr.NumMethod(); i++ {
+		m := ptr.Method(i)
+		methodName := jsName(m.Name)
+		if _, ok := c.fields[methodName]; ok {
+			return nil, fmt.Errorf("unable to bind the class %s, the method %s and a field are both named %s", name, m.Name, methodName)
+		}
+		b, err := newFuncBinding(rt, name+"."+methodName, zero.Method(i))
+		if err != nil {
+			return nil, err
+		}
+		c.methods[methodName] = b
+		c.methodIndex[methodName] = i
+		methodNames = append(methodNames, methodName)
+	}
+	if ctor != nil {
+		b, err := newFuncBinding(rt, name, reflect.ValueOf(ctor))
+		if err != nil {
+			return nil, err
+		}
+		out := b.fn.Type()
+		if b.async || !b.hasResult || out.Out(0) != ptr && out.Out(0) != typ {
+			return nil, fmt.Errorf("unable to bind the constructor of %s, expect a sync func returning %s or %s, but got %s", name, ptr, typ, out)
+		}
+		c.ctor = b
+	}
+	err := c.createClass(fieldNames, methodNames)
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *classBinding) createClass(fieldNames []string, methodNames []string) error {
+	ctx := c.rt.ctx
+	iso := ctx.Isolate()
+	args := []any{
+		c.name,
+		v8go.NewFunctionTemplate(iso, c.construct).GetFunction(ctx).Value,
+		v8go.NewFunctionTemplate(iso, c.get).GetFunction(ctx).Value,
+		v8go.NewFunctionTemplate(iso, c.set).GetFunction(ctx).Value,
+		v8go.NewFunctionTemplate(iso, c.call).GetFunction(ctx).Value,
+		fieldNames,
+		methodNames,
+	}
+	valArgs := make([]v8go.Valuer, len(args))
+	for i, arg := range args {
+		v, err := MakeValue(ctx, arg)
+		if err != nil {
+			return fmt.Errorf("unable to make the arguments to create the class %s, %w", c.name, err)
+		}
+		valArgs[i] = v
+	}
+	res, err := c.rt.utils.goUtils.MethodCall("create_class", valArgs...)
+	if err != nil {
+		return fmt.Errorf("unable to create the class %s, %w", c.name, AsJSException(err))
+	}
+	parts, err := arrayElements(res)
+	if err != nil {
+		return fmt.Errorf("unable to access the created class %s, %w", c.name, err)
+	}
+	c.class = parts[0]
+	if c.fnWrap, err = parts[1].AsFunction(); err != nil {
+		return err
+	}
+	if c.fnUnwrap, err = parts[2].AsFunction(); err != nil {
+		return err
+	}
+	return nil
+}
+
+// register returns the id of the struct pointer, the same pointer always gets the same id
+func (c *classBinding) register(rv reflect.Value) int32 {
+	key := rv.Interface()
+	if id, ok := c.ids[key]; ok {
+		return id
+	}
+	c.nextID++
+	c.instances[c.nextID] = rv
+	c.ids[key] = c.nextID
+	return c.nextID
+}
+
+func (c *classBinding) throw(ctx *v8go.Context, err error) *v8go.Value {
+	return ctx.Isolate().ThrowException(mustWrapError(c.rt.utils, err))
+}
+
+func (c *classBinding) typeError(format string, args ...any) error {
+	return &JSException{Name: "TypeError", Message: fmt.Sprintf(format, args...)}
+}
+
+// instance returns the instance of the id passed as the first argument and the member name passed as the second
+func (c *classBinding) instance(info *v8go.FunctionCallbackInfo) (reflect.Value, string, error) {
+	args := info.Args()
+	if len(args) < 2 || !args[0].IsInt32() {
+		return reflect.Value{}, "", c.typeError("invalid call on %s", c.name)
+	}
+	rv, ok := c.instances[args[0].Int32()]
+	if !ok {
+		return reflect.Value{}, "", c.typeError("the receiver is not an instance of %s", c.name)
+	}
+	return rv, args[1].String(), nil
+}
+
+func (c *classBinding) construct(info *v8go.FunctionCallbackInfo) *v8go.Value {
+	ctx := info.Context()
+	if c.ctor == nil {
+		return c.throw(ctx, c.typeError("%s has no constructor", c.name))
+	}
+	args, err := c.ctor.args(ctx, info.Args())
+	if err != nil {
+		return c.throw(ctx, err)
+	}
+	result, err := c.ctor.call(args)
+	if err != nil {
+		return c.throw(ctx, err)
+	}
+	rv := reflect.ValueOf(result)
+	if rv.Kind() != reflect.Pointer {
+		copied := reflect.New(c.typ)
+		copied.Elem().Set(rv)
+		rv = copied
+	} else if rv.IsNil() {
+		return c.throw(ctx, c.typeError("the constructor of %s returned nil", c.name))
+	}
+	return mustNewValue(ctx.Isolate(), c.register(rv))
+}
+
+func (c *classBinding) get(info *v8go.FunctionCallbackInfo) *v8go.Value {
+	ctx := info.Context()
+	rv, name, err := c.instance(info)
+	if err != nil {
+		return c.throw(ctx, err)
+	}
+	f, ok := c.fields[name]
+	if !ok {
+		return v8go.Undefined(ctx.Isolate())
+	}
+	fv, ok := fieldByIndex(rv.Elem(), f.index)
+	if !ok {
+		return v8go.Undefined(ctx.Isolate())
+	}
+	value, err := makeElemValue(ctx, fv, 0)
+	if err != nil {
+		return c.throw(ctx, fmt.Errorf("unable to make the value of %s.%s, %w", c.name, name, err))
+	}
+	return value
+}
+
+func (c *classBinding) set(info *v8go.FunctionCallbackInfo) *v8go.Value {
+	ctx := info.Context()
+	rv, name, err := c.instance(info)
+	if err != nil {
+		return c.throw(ctx, err)
+	}
+	value := v8go.Undefined(ctx.Isolate())
+	if len(info.Args()) > 2 {
+		value = info.Args()[2]
+	}
+	f, ok := c.fields[name]
+	if !ok {
+		return c.throw(ctx, c.typeError("%s has no field %s", c.name, name))
+	}
+	fv, _ := settableFieldByIndex(rv.Elem(), f.index)
+	if !fv.IsValid() || !fv.CanSet() {
+		return c.throw(ctx, c.typeError("%s.%s is read-only", c.name, name))
+	}
+	// decoded into a copy first, so a failure leaves the field unchanged
+	decoded := reflect.New(fv.Type()).Elem()
+	decoded.Set(fv)
+	d := &decoder{ctx: ctx}
+	err = d.decode(value, decoded, name)
+	if err != nil {
+		return c.throw(ctx, &JSException{Name: "TypeError", Message: err.Error(), Cause: err})
+	}
+	fv.Set(decoded)
+	return v8go.Undefined(ctx.Isolate())
+}
+
+func (c *classBinding) call(info *v8go.FunctionCallbackInfo) *v8go.Value {
+	ctx := info.Context()
+	rv, name, err := c.instance(info)
+	if err != nil {
+		return c.throw(ctx, err)
+	}
+	b, ok := c.methods[name]
+	if !ok {
+		return c.throw(ctx, c.typeError("%s.%s is not a function", c.name, name))
+	}
+	bound := *b
+	bound.fn = rv.Method(c.methodIndex[name])
+	return bound.invoke(ctx, info.Args()[2:])
+}
+
+// wrap creates a js instance of the class backed by the struct pointer
+func (c *classBinding) wrap(rv reflect.Value) (*v8go.Value, error) {
+	if rv.IsNil() {
+		return v8go.Null(c.rt.ctx.Isolate()), nil
+	}
+	id := mustNewValue(c.rt.ctx.Isolate(), c.register(rv))
+	return c.fnWrap.Call(v8go.Undefined(c.rt.ctx.Isolate()), id)
+}
+
+func (c *classBinding) unwrap(value *v8go.Value) (reflect.Value, bool) {
+	res, err := c.fnUnwrap.Call(v8go.Undefined(c.rt.ctx.Isolate()), value)
+	if err != nil || !res.IsInt32() {
+		return reflect.Value{}, false
+	}
+	rv, ok := c.instances[res.Int32()]
+	return rv, ok
+}
+
+// Class is a struct type bound to a js class by BindClass.
+type Class[T any] struct {
+	b *classBinding
+}
+
+// BindClass sets the global class name exposing the struct type T. The exported fields of T become
+// properties named by their json tags and its exported methods, including those of *T, become methods
+// named in camel case, called as BindFunc does, a method named as a field is an error. ctor is called
+// by `new`, it takes the js arguments as BindFunc does and returns *T or T, optionally followed by
+// an error. If ctor is nil, the instances can only be created by Wrap. The go values of the instances
+// are referenced as long as the runtime, because v8go has no weak callbacks to release them earlier.
+func BindClass[T any](rt *Runtime, name string, ctor any) (*Class[T], error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	b, err := newClassBinding(rt, name, typ, ctor)
+	if err != nil {
+		return nil, err
+	}
+	err = rt.ctx.Global().Set(name, b.class)
+	if err != nil {
+		return nil, fmt.Errorf("unable to set the global class %s, %w", name, err)
+	}
+	rt.classes[typ] = b
+	return &Class[T]{b: b}, nil
+}
+
+// Constructor returns the js class.
+func (c *Class[T]) Constructor() *v8go.Value {
+	return c.b.class
+}
+
+// Wrap returns a js instance of the class backed by v, the changes made on either side are visible to the other.
+func (c *Class[T]) Wrap(v *T) (*v8go.Value, error) {
+	return c.b.wrap(reflect.ValueOf(v))
+}
+
+// Unwrap returns the go value of a js instance of the class.
+func (c *Class[T]) Unwrap(value *v8go.Value) (*T, bool) {
+	rv, ok := c.b.unwrap(value)
+	if !ok {
+		return nil, false
+	}
+	return rv.Interface().(*T), true
+}
+
+// BindObject sets the global name to an object exposing the fields and the methods of the struct value, as BindClass
+// does. If value is a pointer the changes made on either side are visible to the other, otherwise a copy is exposed.
+// The object is an instance of the class bound to the struct type by BindClass, or of an unexposed class.
+func BindObject(rt *Runtime, name string, value any) error {
+	rv := reflect.ValueOf(value)
+	if rv.Kind() != reflect.Pointer {
+		if rv.Kind() != reflect.Struct {
+			return fmt.Errorf("unable to bind the object %s, expect a struct or a struct pointer, but got %T", name, value)
+		}
+		copied := reflect.New(rv.Type())
+		copied.Elem().Set(rv)
+		rv = copied
+	} else if rv.IsNil() {
+		return fmt.Errorf("unable to bind the object %s, the value is nil", name)
+	}
+	typ := rv.Type().Elem()
+	b, ok := rt.classes[typ]
+	if !ok {
+		className := typ.Name()
+		if className == "" {
+			className = "Object"
+		}
+		var err error
+		b, err = newClassBinding(rt, className, typ, nil)
+		if err != nil {
+			return err
+		}
+		rt.classes[typ] = b
+	}
+	obj, err := b.wrap(rv)
+	if err != nil {
+		return fmt.Errorf("unable to wrap the object %s, %w", name, AsJSException(err))
+	}
+	err = rt.ctx.Global().Set(name, obj)
+	if err != nil {
+		return fmt.Errorf("unable to set the global object %s, %w", name, err)
+	}
+	return nil
+}
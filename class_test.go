@@ -0,0 +1,139 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+type testCounter struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+	Step  int    `json:"-"`
+}
+
+func (c *testCounter) Add(n int) int {
+	c.Count += n * c.Step
+	return c.Count
+}
+
+func (c testCounter) String() string {
+	return fmt.Sprintf("%s=%d", c.Name, c.Count)
+}
+
+func (c *testCounter) Reset(ctx context.Context) error {
+	c.Count = 0
+	return nil
+}
+
+func newTestCounter(name string) (*testCounter, error) {
+	if name == "" {
+		return nil, errors.New("the name is required")
+	}
+	return &testCounter{Name: name, Step: 1}, nil
+}
+
+type testCollision struct {
+	N int `json:"count"`
+}
+
+func (c testCollision) Count() int {
+	return c.N
+}
+
+func TestJSName(t *testing.T) {
+	test.AssertEqual(t, "readFile", jsName("ReadFile"), "")
+	test.AssertEqual(t, "urlPath", jsName("URLPath"), "")
+	test.AssertEqual(t, "id", jsName("ID"), "")
+	test.AssertEqual(t, "x", jsName("X"), "")
+}
+
+func TestBindClass(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	class, err := BindClass[testCounter](rt, "Counter", newTestCounter)
+	panicIfErr(err)
+	res, err := ctx.RunScript(`
+		const c = new Counter('a');
+		c.add(2);
+		c.count += 1;
+		let failure;
+		try {
+			new Counter('');
+		} catch (e) {
+			failure = e.message;
+		}
+		let typeFailure;
+		try {
+			c.count = 'x';
+		} catch (e) {
+			typeFailure = e.name + ': ' + e.message;
+		}
+		[
+			c instanceof Counter, c.name, c.count, c.string(), JSON.stringify(c), failure, typeFailure,
+			Object.keys(Counter.prototype).join(','),
+		].join('|')
+	`, "class.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "true|a|3|a=3|"+`{"name":"a","count":3}`+"|the name is required|TypeError: field \"count\": expected number|name,count",
+		res.String(), "")
+
+	c, err := ctx.RunScript("c", "class.js")
+	panicIfErr(err)
+	counter, ok := class.Unwrap(c)
+	test.MustEqual(t, true, ok, "")
+	test.AssertEqual(t, 3, counter.Count, "")
+
+	promise, err := ctx.RunScript("c.reset()", "class.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, 0, counter.Count, "")
+
+	wrapped, err := class.Wrap(&testCounter{Name: "b", Count: 5, Step: 2})
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("b", wrapped))
+	res, err = ctx.RunScript("b instanceof Counter && b.add(1)", "class.js")
+	panicIfErr(err)
+	test.AssertEqual(t, int64(7), res.Integer(), "")
+	_, ok = class.Unwrap(res)
+	test.AssertEqual(t, false, ok, "")
+
+	_, err = BindClass[testCollision](rt, "Collision", nil)
+	test.AssertEqual(t, "unable to bind the class Collision, the method Count and a field are both named count", fmt.Sprint(err), "")
+}
+
+func TestBindObject(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	counter := &testCounter{Name: "config", Step: 10}
+	panicIfErr(BindObject(rt, "counter", counter))
+	panicIfErr(BindObject(rt, "snapshot", testCounter{Name: "copy"}))
+	res, err := ctx.RunScript(`
+		counter.add(1);
+		counter.name = 'renamed';
+		snapshot.count = 9;
+		[counter.count, snapshot.count, typeof Counter].join('|')
+	`, "object.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "10|9|undefined", res.String(), "")
+	test.AssertEqual(t, "renamed", counter.Name, "")
+	test.AssertEqual(t, 10, counter.Count, "")
+
+	res, err = ctx.RunScript(`
+		try {
+			new counter.constructor();
+		} catch (e) {
+			e.message
+		}
+	`, "object.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "testCounter has no constructor", res.String(), "")
+
+	test.AssertEqual(t, true, BindObject(rt, "bad", 1) != nil, "")
+}
@@ -0,0 +1,187 @@
+package v8tsgo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/gob"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+
+	v8 "rogchap.com/v8go"
+)
+
+var ErrStaleCodeCache = errors.New("the code cache does not match the current v8 version or bundle")
+
+const (
+	codeCacheMagic         = "V8TSGOCC"
+	codeCacheFormatVersion = uint32(1)
+)
+
+// CodeCache holds the v8 code caches of the bundle scripts, so the isolates booted with it skip
+// parsing and compiling typescript.js. It is not a startup snapshot: v8go v0.9.0 does not expose
+// v8::SnapshotCreator, so the scripts are still executed on every boot and only the compilation,
+// including the functions compiled lazily while creating the cache, is saved.
+type CodeCache struct {
+	V8Version  string
+	BundleHash string
+	// code caches keyed by the script name
+	Caches map[string][]byte
+}
+
+// codeCacheData has no methods, so gob doesn't call back into MarshalBinary
+type codeCacheData CodeCache
+
+func hashBundleScripts(scripts []bundleScript) string {
+	h := sha256.New()
+	for _, script := range scripts {
+		fmt.Fprintf(h, "%s\x00%d\x00", script.name, len(script.source))
+		h.Write([]byte(script.source))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// CreateCodeCache evaluates the bundle in a new isolate and collects the code caches of its scripts.
+// Only the WithBundle option is used.
+func CreateCodeCache(opts ...CompilerOption) (*CodeCache, error) {
+	cfg := newCompilerConfig(opts)
+	scripts, err := readBundleScripts(cfg.bundle)
+	if err != nil {
+		return nil, err
+	}
+	iso := v8.NewIsolate()
+	defer iso.Dispose()
+	ctx := v8.NewContext(iso)
+	defer ctx.Close()
+	compiled := make([]*v8.UnboundScript, 0, len(scripts))
+	for _, script := range scripts {
+		us, err := iso.CompileUnboundScript(script.source, script.name, v8.CompileOptions{Mode: v8.CompileModeEager})
+		if err != nil {
+			return nil, fmt.Errorf("failed to compile %s, %w", script.name, err)
+		}
+		_, err = us.Run(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute %s, %w", script.name, err)
+		}
+		compiled = append(compiled, us)
+	}
+	cache := &CodeCache{
+		V8Version:  v8.Version(),
+		BundleHash: hashBundleScripts(scripts),
+		Caches:     make(map[string][]byte, len(scripts)),
+	}
+	// created after all the scripts ran, so the lazily compiled functions are included
+	for i, us := range compiled {
+		cache.Caches[scripts[i].name] = us.CreateCodeCache().Bytes
+	}
+	return cache, nil
+}
+
+func (s *CodeCache) check(scripts []bundleScript) error {
+	if s.V8Version != v8.Version() {
+		return fmt.Errorf("%w, created by v8 %s, but the current version is %s", ErrStaleCodeCache, s.V8Version, v8.Version())
+	}
+	if s.BundleHash != hashBundleScripts(scripts) {
+		return fmt.Errorf("%w, the bundle has changed", ErrStaleCodeCache)
+	}
+	return nil
+}
+
+func (s *CodeCache) run(ctx *v8.Context, scripts []bundleScript) error {
+	err := s.check(scripts)
+	if err != nil {
+		return err
+	}
+	iso := ctx.Isolate()
+	for _, script := range scripts {
+		var opts v8.CompileOptions
+		if cache := s.Caches[script.name]; len(cache) > 0 {
+			opts.CachedData = &v8.CompilerCachedData{Bytes: cache}
+		}
+		us, err := iso.CompileUnboundScript(script.source, script.name, opts)
+		if err != nil {
+			return fmt.Errorf("failed to compile %s, %w", script.name, err)
+		}
+		// v8 compiles the script from scratch if it rejects the cache
+		if opts.CachedData != nil && opts.CachedData.Rejected {
+			return fmt.Errorf("%w, v8 rejected the code cache of %s", ErrStaleCodeCache, script.name)
+		}
+		_, err = us.Run(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to execute %s, %w", script.name, err)
+		}
+	}
+	return nil
+}
+
+// MarshalBinary encodes the code cache behind a header checked by UnmarshalBinary.
+func (s *CodeCache) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(codeCacheMagic)
+	err := binary.Write(&buf, binary.LittleEndian, codeCacheFormatVersion)
+	if err != nil {
+		return nil, err
+	}
+	err = gob.NewEncoder(&buf).Encode((*codeCacheData)(s))
+	if err != nil {
+		return nil, fmt.Errorf("unable to encode the code cache, %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// UnmarshalBinary decodes the data created by MarshalBinary,
+// it returns ErrStaleCodeCache if the code cache was created by another v8 version or format.
+func (s *CodeCache) UnmarshalBinary(data []byte) error {
+	if len(data) < len(codeCacheMagic)+4 || string(data[:len(codeCacheMagic)]) != codeCacheMagic {
+		return errors.New("the data is not a v8tsgo code cache")
+	}
+	data = data[len(codeCacheMagic):]
+	version := binary.LittleEndian.Uint32(data)
+	if version != codeCacheFormatVersion {
+		return fmt.Errorf("%w, unsupported code cache format %d", ErrStaleCodeCache, version)
+	}
+	var decoded codeCacheData
+	err := gob.NewDecoder(bytes.NewReader(data[4:])).Decode(&decoded)
+	if err != nil {
+		return fmt.Errorf("unable to decode the code cache, %w", err)
+	}
+	if decoded.V8Version != v8.Version() {
+		return fmt.Errorf("%w, created by v8 %s, but the current version is %s", ErrStaleCodeCache, decoded.V8Version, v8.Version())
+	}
+	*s = CodeCache(decoded)
+	return nil
+}
+
+func (s *CodeCache) WriteFile(path string) error {
+	data, err := s.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return fmt.Errorf("unable to write the code cache to \"%s\", %w", path, err)
+	}
+	return nil
+}
+
+func ReadCodeCacheFile(path string) (*CodeCache, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read the code cache from \"%s\", %w", path, err)
+	}
+	var s CodeCache
+	err = s.UnmarshalBinary(data)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// WithCodeCache boots the isolates from the code cache, it must be created from the same bundle.
+func WithCodeCache(cache *CodeCache) CompilerOption {
+	return func(cfg *compilerConfig) {
+		cfg.codeCache = cache
+	}
+}
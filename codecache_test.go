@@ -0,0 +1,65 @@
+package v8tsgo
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestCodeCache(t *testing.T) {
+	bundle := newTestBundle(t)
+	s, err := CreateCodeCache(WithBundle(bundle))
+	panicIfErr(err)
+	test.AssertEqual(t, 3, len(s.Caches), "")
+
+	path := filepath.Join(t.TempDir(), "ts.codecache")
+	panicIfErr(s.WriteFile(path))
+	loaded, err := ReadCodeCacheFile(path)
+	panicIfErr(err)
+	test.AssertEqual(t, s.BundleHash, loaded.BundleHash, "")
+
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.WriteFile("/a.ts", "let a: number = 1;"))
+	// NewCompiler fails if v8 rejects one of the caches
+	c, err := NewCompiler(mfs, WithBundle(bundle), WithCodeCache(loaded))
+	panicIfErr(err)
+	defer c.Close()
+	out, err := c.Transpile("/a.ts", nil)
+	panicIfErr(err)
+	test.AssertEqual(t, "let a = 1;", out.OutputText, "")
+}
+
+func TestStaleCodeCache(t *testing.T) {
+	bundle := newTestBundle(t).(fstest.MapFS)
+	s, err := CreateCodeCache(WithBundle(bundle))
+	panicIfErr(err)
+
+	changed := fstest.MapFS{}
+	for name, file := range bundle {
+		changed[name] = file
+	}
+	changed[bundleBootstrap] = &fstest.MapFile{Data: append([]byte("// changed\n"), bundle[bundleBootstrap].Data...)}
+	_, err = NewTranspiler(WithBundle(changed), WithCodeCache(s))
+	test.AssertEqual(t, true, errors.Is(err, ErrStaleCodeCache), "")
+
+	// the cache of another script is rejected by v8
+	mismatched := &CodeCache{V8Version: s.V8Version, BundleHash: s.BundleHash, Caches: map[string][]byte{
+		bundleTypescript: s.Caches[bundleBootstrap],
+	}}
+	_, err = NewTranspiler(WithBundle(bundle), WithCodeCache(mismatched))
+	test.AssertEqual(t, true, errors.Is(err, ErrStaleCodeCache), "")
+
+	s.V8Version = "0.0.0"
+	data, err := s.MarshalBinary()
+	panicIfErr(err)
+	var loaded CodeCache
+	err = loaded.UnmarshalBinary(data)
+	test.AssertEqual(t, true, errors.Is(err, ErrStaleCodeCache), "")
+
+	err = loaded.UnmarshalBinary([]byte("not a code cache"))
+	test.AssertEqual(t, true, err != nil && !errors.Is(err, ErrStaleCodeCache), "")
+}
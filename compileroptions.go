@@ -0,0 +1,378 @@
+package v8tsgo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Ptr returns a pointer to the value, to set the optional fields of CompilerOptions.
+func Ptr[T any](v T) *T {
+	return &v
+}
+
+// CompilerOptions mirrors ts.CompilerOptions, the nil fields are not set. Its json form is the one of tsconfig,
+// the enums are names, and ToAPI returns the form of the typescript api. The options without a field are kept in Extra.
+type CompilerOptions struct {
+	// language and environment
+	Target                  *ScriptTarget        `json:"target,omitempty"`
+	Lib                     []string             `json:"lib,omitempty"`
+	Jsx                     *JsxEmit             `json:"jsx,omitempty"`
+	JsxFactory              string               `json:"jsxFactory,omitempty"`
+	JsxFragmentFactory      string               `json:"jsxFragmentFactory,omitempty"`
+	JsxImportSource         string               `json:"jsxImportSource,omitempty"`
+	ReactNamespace          string               `json:"reactNamespace,omitempty"`
+	NoLib                   *bool                `json:"noLib,omitempty"`
+	UseDefineForClassFields *bool                `json:"useDefineForClassFields,omitempty"`
+	ModuleDetection         *ModuleDetectionKind `json:"moduleDetection,omitempty"`
+	ExperimentalDecorators  *bool                `json:"experimentalDecorators,omitempty"`
+	EmitDecoratorMetadata   *bool                `json:"emitDecoratorMetadata,omitempty"`
+
+	// modules
+	Module           *ModuleKind           `json:"module,omitempty"`
+	ModuleResolution *ModuleResolutionKind `json:"moduleResolution,omitempty"`
+	BaseUrl          string                `json:"baseUrl,omitempty"`
+	Paths            map[string][]string   `json:"paths,omitempty"`
+	// PathsBasePath is the directory the paths are relative to without a baseUrl, LoadProject sets it
+	// to the directory of the tsconfig defining the paths.
+	PathsBasePath                    string   `json:"pathsBasePath,omitempty"`
+	RootDir                          string   `json:"rootDir,omitempty"`
+	RootDirs                         []string `json:"rootDirs,omitempty"`
+	TypeRoots                        []string `json:"typeRoots,omitempty"`
+	Types                            []string `json:"types,omitempty"`
+	ModuleSuffixes                   []string `json:"moduleSuffixes,omitempty"`
+	CustomConditions                 []string `json:"customConditions,omitempty"`
+	AllowArbitraryExtensions         *bool    `json:"allowArbitraryExtensions,omitempty"`
+	AllowImportingTsExtensions       *bool    `json:"allowImportingTsExtensions,omitempty"`
+	AllowUmdGlobalAccess             *bool    `json:"allowUmdGlobalAccess,omitempty"`
+	ResolveJsonModule                *bool    `json:"resolveJsonModule,omitempty"`
+	ResolvePackageJsonExports        *bool    `json:"resolvePackageJsonExports,omitempty"`
+	ResolvePackageJsonImports        *bool    `json:"resolvePackageJsonImports,omitempty"`
+	NoResolve                        *bool    `json:"noResolve,omitempty"`
+	NoUncheckedSideEffectImports     *bool    `json:"noUncheckedSideEffectImports,omitempty"`
+	MaxNodeModuleJsDepth             *int     `json:"maxNodeModuleJsDepth,omitempty"`
+	AllowJs                          *bool    `json:"allowJs,omitempty"`
+	CheckJs                          *bool    `json:"checkJs,omitempty"`
+	PreserveSymlinks                 *bool    `json:"preserveSymlinks,omitempty"`
+	ForceConsistentCasingInFileNames *bool    `json:"forceConsistentCasingInFileNames,omitempty"`
+
+	// emit
+	NoEmit              *bool        `json:"noEmit,omitempty"`
+	NoEmitOnError       *bool        `json:"noEmitOnError,omitempty"`
+	NoEmitHelpers       *bool        `json:"noEmitHelpers,omitempty"`
+	ImportHelpers       *bool        `json:"importHelpers,omitempty"`
+	OutDir              string       `json:"outDir,omitempty"`
+	OutFile             string       `json:"outFile,omitempty"`
+	Declaration         *bool        `json:"declaration,omitempty"`
+	DeclarationDir      string       `json:"declarationDir,omitempty"`
+	DeclarationMap      *bool        `json:"declarationMap,omitempty"`
+	EmitDeclarationOnly *bool        `json:"emitDeclarationOnly,omitempty"`
+	SourceMap           *bool        `json:"sourceMap,omitempty"`
+	InlineSourceMap     *bool        `json:"inlineSourceMap,omitempty"`
+	InlineSources       *bool        `json:"inlineSources,omitempty"`
+	MapRoot             string       `json:"mapRoot,omitempty"`
+	SourceRoot          string       `json:"sourceRoot,omitempty"`
+	RemoveComments      *bool        `json:"removeComments,omitempty"`
+	NewLine             *NewLineKind `json:"newLine,omitempty"`
+	EmitBOM             *bool        `json:"emitBOM,omitempty"`
+	DownlevelIteration  *bool        `json:"downlevelIteration,omitempty"`
+	PreserveConstEnums  *bool        `json:"preserveConstEnums,omitempty"`
+	StripInternal       *bool        `json:"stripInternal,omitempty"`
+
+	// interop constraints
+	IsolatedModules              *bool `json:"isolatedModules,omitempty"`
+	IsolatedDeclarations         *bool `json:"isolatedDeclarations,omitempty"`
+	VerbatimModuleSyntax         *bool `json:"verbatimModuleSyntax,omitempty"`
+	AllowSyntheticDefaultImports *bool `json:"allowSyntheticDefaultImports,omitempty"`
+	EsModuleInterop              *bool `json:"esModuleInterop,omitempty"`
+
+	// type checking
+	Strict                             *bool `json:"strict,omitempty"`
+	AlwaysStrict                       *bool `json:"alwaysStrict,omitempty"`
+	NoImplicitAny                      *bool `json:"noImplicitAny,omitempty"`
+	NoImplicitThis                     *bool `json:"noImplicitThis,omitempty"`
+	NoImplicitReturns                  *bool `json:"noImplicitReturns,omitempty"`
+	NoImplicitOverride                 *bool `json:"noImplicitOverride,omitempty"`
+	StrictNullChecks                   *bool `json:"strictNullChecks,omitempty"`
+	StrictFunctionTypes                *bool `json:"strictFunctionTypes,omitempty"`
+	StrictBindCallApply                *bool `json:"strictBindCallApply,omitempty"`
+	StrictPropertyInitialization       *bool `json:"strictPropertyInitialization,omitempty"`
+	StrictBuiltinIteratorReturn        *bool `json:"strictBuiltinIteratorReturn,omitempty"`
+	UseUnknownInCatchVariables         *bool `json:"useUnknownInCatchVariables,omitempty"`
+	ExactOptionalPropertyTypes         *bool `json:"exactOptionalPropertyTypes,omitempty"`
+	NoUncheckedIndexedAccess           *bool `json:"noUncheckedIndexedAccess,omitempty"`
+	NoPropertyAccessFromIndexSignature *bool `json:"noPropertyAccessFromIndexSignature,omitempty"`
+	NoFallthroughCasesInSwitch         *bool `json:"noFallthroughCasesInSwitch,omitempty"`
+	NoUnusedLocals                     *bool `json:"noUnusedLocals,omitempty"`
+	NoUnusedParameters                 *bool `json:"noUnusedParameters,omitempty"`
+	AllowUnreachableCode               *bool `json:"allowUnreachableCode,omitempty"`
+	AllowUnusedLabels                  *bool `json:"allowUnusedLabels,omitempty"`
+	NoCheck                            *bool `json:"noCheck,omitempty"`
+
+	// completeness
+	SkipLibCheck        *bool `json:"skipLibCheck,omitempty"`
+	SkipDefaultLibCheck *bool `json:"skipDefaultLibCheck,omitempty"`
+
+	// projects
+	Composite                                 *bool  `json:"composite,omitempty"`
+	Incremental                               *bool  `json:"incremental,omitempty"`
+	TsBuildInfoFile                           string `json:"tsBuildInfoFile,omitempty"`
+	DisableReferencedProjectLoad              *bool  `json:"disableReferencedProjectLoad,omitempty"`
+	DisableSolutionSearching                  *bool  `json:"disableSolutionSearching,omitempty"`
+	DisableSourceOfProjectReferenceRedirect   *bool  `json:"disableSourceOfProjectReferenceRedirect,omitempty"`
+	AssumeChangesOnlyAffectDirectDependencies *bool  `json:"assumeChangesOnlyAffectDirectDependencies,omitempty"`
+	// ConfigFilePath is the tsconfig file the options are loaded from.
+	ConfigFilePath string `json:"configFilePath,omitempty"`
+
+	// diagnostics and output
+	NoErrorTruncation            *bool  `json:"noErrorTruncation,omitempty"`
+	Diagnostics                  *bool  `json:"diagnostics,omitempty"`
+	ExtendedDiagnostics          *bool  `json:"extendedDiagnostics,omitempty"`
+	ExplainFiles                 *bool  `json:"explainFiles,omitempty"`
+	ListFiles                    *bool  `json:"listFiles,omitempty"`
+	ListEmittedFiles             *bool  `json:"listEmittedFiles,omitempty"`
+	TraceResolution              *bool  `json:"traceResolution,omitempty"`
+	Pretty                       *bool  `json:"pretty,omitempty"`
+	DisableSizeLimit             *bool  `json:"disableSizeLimit,omitempty"`
+	IgnoreDeprecations           string `json:"ignoreDeprecations,omitempty"`
+	SuppressExcessPropertyErrors *bool  `json:"suppressExcessPropertyErrors,omitempty"`
+
+	Plugins []map[string]any `json:"plugins,omitempty"`
+
+	// Extra are the options without a field, as parsed from json.
+	Extra map[string]any `json:"-"`
+}
+
+// compilerOptionsFields has no methods, so encoding/json handles its fields
+type compilerOptionsFields CompilerOptions
+
+// the options holding paths, they are relative to the tsconfig file defining them
+var compilerOptionPaths = map[string]bool{
+	"baseUrl":         true,
+	"rootDir":         true,
+	"outDir":          true,
+	"outFile":         true,
+	"declarationDir":  true,
+	"tsBuildInfoFile": true,
+}
+
+// the options holding lists of paths
+var compilerOptionPathLists = map[string]bool{
+	"rootDirs":  true,
+	"typeRoots": true,
+}
+
+func compilerOptionIndexes() map[string][]int {
+	fields := map[string][]int{}
+	for _, f := range structFields(reflect.TypeOf(CompilerOptions{})) {
+		fields[f.name] = f.index
+	}
+	return fields
+}
+
+// the lib files whose name is not the tsconfig name
+var libAliases = map[string]string{
+	"es6": "es2015",
+	"es7": "es2016",
+}
+
+// LibFileName converts the tsconfig lib name to the lib file name of the api, such as "dom" to "lib.dom.d.ts".
+func LibFileName(name string) string {
+	name = strings.ToLower(name)
+	if alias, ok := libAliases[name]; ok {
+		name = alias
+	}
+	return "lib." + name + ".d.ts"
+}
+
+// LibName converts the lib file name of the api to the tsconfig lib name, the other names are returned as is.
+func LibName(fileName string) string {
+	if strings.HasPrefix(fileName, "lib.") && strings.HasSuffix(fileName, ".d.ts") && len(fileName) > len("lib..d.ts") {
+		return fileName[len("lib.") : len(fileName)-len(".d.ts")]
+	}
+	return fileName
+}
+
+func libNames(files []string) []string {
+	names := make([]string, len(files))
+	for i, file := range files {
+		names[i] = LibName(file)
+	}
+	return names
+}
+
+// setOption decodes the json into the field of the option, the options without a field are set in Extra
+func (o *CompilerOptions) setOption(indexes map[string][]int, name string, data []byte) error {
+	index, ok := indexes[name]
+	if !ok {
+		var value any
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		if o.Extra == nil {
+			o.Extra = map[string]any{}
+		}
+		o.Extra[name] = value
+		return nil
+	}
+	field := reflect.ValueOf(o).Elem().FieldByIndex(index)
+	if string(data) == "null" {
+		field.SetZero()
+		return nil
+	}
+	value := reflect.New(field.Type())
+	if err := json.Unmarshal(data, value.Interface()); err != nil {
+		return err
+	}
+	field.Set(value.Elem())
+	return nil
+}
+
+// optionError converts the error of setOption to the diagnostic typescript reports
+func optionError(fileName string, name string, fieldType reflect.Type, err error) Diagnostic {
+	d := Diagnostic{
+		File:     fileName,
+		Code:     diagnosticOptionType,
+		Category: DiagnosticCategoryError,
+		Message:  fmt.Sprintf("Compiler option '%s' requires a value of type %s.", name, jsonTypeName(fieldType)),
+	}
+	var enumErr *EnumValueError
+	if errors.As(err, &enumErr) {
+		d.Code = diagnosticOptionValue
+		d.Message = fmt.Sprintf("Argument for '--%s' option must be: %s.", name, enumErr.Allowed)
+	}
+	return d
+}
+
+// jsonTypeName names the json type of the go type in the diagnostics, as typescript does
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return jsonTypeName(t.Elem())
+	case reflect.Bool:
+		return "boolean"
+	case reflect.String:
+		return "string"
+	case reflect.Int:
+		return "number"
+	case reflect.Slice:
+		return "Array"
+	case reflect.Map:
+		return "object"
+	default:
+		return t.String()
+	}
+}
+
+func (o *CompilerOptions) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*o = CompilerOptions{}
+	indexes := compilerOptionIndexes()
+	for name, value := range raw {
+		if err := o.setOption(indexes, name, value); err != nil {
+			return fmt.Errorf("unable to decode the compiler option \"%s\", %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (o CompilerOptions) MarshalJSON() ([]byte, error) {
+	data, err := json.Marshal(compilerOptionsFields(o))
+	if err != nil || len(o.Extra) == 0 {
+		return data, err
+	}
+	var options map[string]any
+	if err = json.Unmarshal(data, &options); err != nil {
+		return nil, err
+	}
+	for name, value := range o.Extra {
+		if _, ok := options[name]; !ok {
+			options[name] = value
+		}
+	}
+	return json.Marshal(options)
+}
+
+// ToAPI returns the options in the form of the typescript api, the one the Compiler methods take.
+// The enums are numbers and the lib entries are lib file names.
+func (o CompilerOptions) ToAPI() map[string]any {
+	options := map[string]any{}
+	for name, value := range o.Extra {
+		options[name] = value
+	}
+	rv := reflect.ValueOf(o)
+	for _, f := range structFields(rv.Type()) {
+		field := rv.FieldByIndex(f.index)
+		if isEmptyValue(field) {
+			continue
+		}
+		if field.Kind() == reflect.Pointer {
+			field = field.Elem()
+		}
+		if field.Kind() == reflect.Int {
+			options[f.name] = int(field.Int())
+		} else {
+			options[f.name] = field.Interface()
+		}
+	}
+	if len(o.Lib) > 0 {
+		lib := make([]string, len(o.Lib))
+		for i, name := range o.Lib {
+			lib[i] = LibFileName(name)
+		}
+		options["lib"] = lib
+	}
+	return options
+}
+
+// CompilerOptionsFromAPI converts the options in the form of the typescript api, the enums may be numbers
+// or names and the lib entries are lib file names.
+func CompilerOptionsFromAPI(options map[string]any) (CompilerOptions, error) {
+	var out CompilerOptions
+	indexes := compilerOptionIndexes()
+	for name, value := range options {
+		if files, ok := value.([]string); ok && name == "lib" {
+			value = libNames(files)
+		} else if files, ok := stringList(value); ok && name == "lib" {
+			value = libNames(files)
+		}
+		data, err := json.Marshal(value)
+		if err == nil {
+			err = out.setOption(indexes, name, data)
+		}
+		if err != nil {
+			return CompilerOptions{}, fmt.Errorf("unable to decode the compiler option \"%s\", %w", name, err)
+		}
+	}
+	return out, nil
+}
+
+// decodeCompilerOptions decodes the options parsed from a tsconfig file, the invalid options are
+// reported by the diagnostics and dropped.
+func decodeCompilerOptions(fileName string, options map[string]any) (CompilerOptions, []Diagnostic) {
+	var out CompilerOptions
+	var diagnostics []Diagnostic
+	indexes := compilerOptionIndexes()
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		data, err := json.Marshal(options[name])
+		if err == nil {
+			err = out.setOption(indexes, name, data)
+		}
+		if err != nil {
+			fieldType := reflect.TypeOf(out).FieldByIndex(indexes[name]).Type
+			diagnostics = append(diagnostics, optionError(fileName, name, fieldType, err))
+		}
+	}
+	return out, diagnostics
+}
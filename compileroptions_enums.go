@@ -0,0 +1,328 @@
+package v8tsgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// The enums of the compiler options have the values of the typescript api, their text form is the one of tsconfig.
+// The values unknown to this package are kept, they are written as numbers.
+
+type enumName struct {
+	value int
+	name  string
+}
+
+// enumNames are the tsconfig names of an enum, the first name of a value is the canonical one
+type enumNames []enumName
+
+func (names enumNames) format(value int) string {
+	for _, n := range names {
+		if n.value == value {
+			return n.name
+		}
+	}
+	return strconv.Itoa(value)
+}
+
+func (names enumNames) allowed() string {
+	var quoted []string
+	seen := map[string]bool{}
+	for _, n := range names {
+		if !seen[n.name] {
+			seen[n.name] = true
+			quoted = append(quoted, "'"+n.name+"'")
+		}
+	}
+	return strings.Join(quoted, ", ")
+}
+
+func (names enumNames) parse(text string) (int, error) {
+	for _, n := range names {
+		if strings.EqualFold(n.name, text) {
+			return n.value, nil
+		}
+	}
+	if v, err := strconv.Atoi(text); err == nil {
+		return v, nil
+	}
+	return 0, &EnumValueError{Value: text, Allowed: names.allowed()}
+}
+
+func (names enumNames) marshalJSON(value int) ([]byte, error) {
+	for _, n := range names {
+		if n.value == value {
+			return json.Marshal(n.name)
+		}
+	}
+	return []byte(strconv.Itoa(value)), nil
+}
+
+// unmarshalJSON accepts the tsconfig names and the api numbers
+func (names enumNames) unmarshalJSON(data []byte) (int, error) {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		return names.parse(text)
+	}
+	var value int
+	if err := json.Unmarshal(data, &value); err != nil {
+		return 0, &EnumValueError{Value: string(data), Allowed: names.allowed()}
+	}
+	return value, nil
+}
+
+// EnumValueError is returned when a compiler option enum is neither a known name nor a number.
+type EnumValueError struct {
+	Value string
+	// Allowed are the quoted names, separated by commas.
+	Allowed string
+}
+
+func (e *EnumValueError) Error() string {
+	return fmt.Sprintf("invalid value %s, it must be one of %s", e.Value, e.Allowed)
+}
+
+// ScriptTarget mirrors ts.ScriptTarget.
+type ScriptTarget int
+
+const (
+	ScriptTargetES3    ScriptTarget = 0
+	ScriptTargetES5    ScriptTarget = 1
+	ScriptTargetES2015 ScriptTarget = 2
+	ScriptTargetES2016 ScriptTarget = 3
+	ScriptTargetES2017 ScriptTarget = 4
+	ScriptTargetES2018 ScriptTarget = 5
+	ScriptTargetES2019 ScriptTarget = 6
+	ScriptTargetES2020 ScriptTarget = 7
+	ScriptTargetES2021 ScriptTarget = 8
+	ScriptTargetES2022 ScriptTarget = 9
+	ScriptTargetES2023 ScriptTarget = 10
+	ScriptTargetESNext ScriptTarget = 99
+)
+
+var scriptTargetNames = enumNames{
+	{0, "es3"}, {1, "es5"}, {2, "es2015"}, {2, "es6"}, {3, "es2016"}, {4, "es2017"}, {5, "es2018"},
+	{6, "es2019"}, {7, "es2020"}, {8, "es2021"}, {9, "es2022"}, {10, "es2023"}, {99, "esnext"},
+}
+
+func (t ScriptTarget) String() string {
+	return scriptTargetNames.format(int(t))
+}
+
+func (t ScriptTarget) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+func (t *ScriptTarget) UnmarshalText(text []byte) error {
+	v, err := scriptTargetNames.parse(string(text))
+	*t = ScriptTarget(v)
+	return err
+}
+
+func (t ScriptTarget) MarshalJSON() ([]byte, error) {
+	return scriptTargetNames.marshalJSON(int(t))
+}
+
+func (t *ScriptTarget) UnmarshalJSON(data []byte) error {
+	v, err := scriptTargetNames.unmarshalJSON(data)
+	*t = ScriptTarget(v)
+	return err
+}
+
+// ModuleKind mirrors ts.ModuleKind.
+type ModuleKind int
+
+const (
+	ModuleKindNone     ModuleKind = 0
+	ModuleKindCommonJS ModuleKind = 1
+	ModuleKindAMD      ModuleKind = 2
+	ModuleKindUMD      ModuleKind = 3
+	ModuleKindSystem   ModuleKind = 4
+	ModuleKindES2015   ModuleKind = 5
+	ModuleKindES2020   ModuleKind = 6
+	ModuleKindES2022   ModuleKind = 7
+	ModuleKindESNext   ModuleKind = 99
+	ModuleKindNode16   ModuleKind = 100
+	ModuleKindNodeNext ModuleKind = 199
+	ModuleKindPreserve ModuleKind = 200
+)
+
+var moduleKindNames = enumNames{
+	{0, "none"}, {1, "commonjs"}, {2, "amd"}, {3, "umd"}, {4, "system"}, {5, "es2015"}, {5, "es6"},
+	{6, "es2020"}, {7, "es2022"}, {99, "esnext"}, {100, "node16"}, {199, "nodenext"}, {200, "preserve"},
+}
+
+func (k ModuleKind) String() string {
+	return moduleKindNames.format(int(k))
+}
+
+func (k ModuleKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *ModuleKind) UnmarshalText(text []byte) error {
+	v, err := moduleKindNames.parse(string(text))
+	*k = ModuleKind(v)
+	return err
+}
+
+func (k ModuleKind) MarshalJSON() ([]byte, error) {
+	return moduleKindNames.marshalJSON(int(k))
+}
+
+func (k *ModuleKind) UnmarshalJSON(data []byte) error {
+	v, err := moduleKindNames.unmarshalJSON(data)
+	*k = ModuleKind(v)
+	return err
+}
+
+// ModuleResolutionKind mirrors ts.ModuleResolutionKind.
+type ModuleResolutionKind int
+
+const (
+	ModuleResolutionKindClassic  ModuleResolutionKind = 1
+	ModuleResolutionKindNode10   ModuleResolutionKind = 2
+	ModuleResolutionKindNode16   ModuleResolutionKind = 3
+	ModuleResolutionKindNodeNext ModuleResolutionKind = 99
+	ModuleResolutionKindBundler  ModuleResolutionKind = 100
+)
+
+var moduleResolutionKindNames = enumNames{
+	{1, "classic"}, {2, "node10"}, {2, "node"}, {3, "node16"}, {99, "nodenext"}, {100, "bundler"},
+}
+
+func (k ModuleResolutionKind) String() string {
+	return moduleResolutionKindNames.format(int(k))
+}
+
+func (k ModuleResolutionKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *ModuleResolutionKind) UnmarshalText(text []byte) error {
+	v, err := moduleResolutionKindNames.parse(string(text))
+	*k = ModuleResolutionKind(v)
+	return err
+}
+
+func (k ModuleResolutionKind) MarshalJSON() ([]byte, error) {
+	return moduleResolutionKindNames.marshalJSON(int(k))
+}
+
+func (k *ModuleResolutionKind) UnmarshalJSON(data []byte) error {
+	v, err := moduleResolutionKindNames.unmarshalJSON(data)
+	*k = ModuleResolutionKind(v)
+	return err
+}
+
+// JsxEmit mirrors ts.JsxEmit.
+type JsxEmit int
+
+const (
+	JsxEmitNone        JsxEmit = 0
+	JsxEmitPreserve    JsxEmit = 1
+	JsxEmitReact       JsxEmit = 2
+	JsxEmitReactNative JsxEmit = 3
+	JsxEmitReactJSX    JsxEmit = 4
+	JsxEmitReactJSXDev JsxEmit = 5
+)
+
+// "none" is not accepted by tsconfig, it is only the default of the api
+var jsxEmitNames = enumNames{
+	{0, "none"}, {1, "preserve"}, {2, "react"}, {3, "react-native"}, {4, "react-jsx"}, {5, "react-jsxdev"},
+}
+
+func (e JsxEmit) String() string {
+	return jsxEmitNames.format(int(e))
+}
+
+func (e JsxEmit) MarshalText() ([]byte, error) {
+	return []byte(e.String()), nil
+}
+
+func (e *JsxEmit) UnmarshalText(text []byte) error {
+	v, err := jsxEmitNames.parse(string(text))
+	*e = JsxEmit(v)
+	return err
+}
+
+func (e JsxEmit) MarshalJSON() ([]byte, error) {
+	return jsxEmitNames.marshalJSON(int(e))
+}
+
+func (e *JsxEmit) UnmarshalJSON(data []byte) error {
+	v, err := jsxEmitNames.unmarshalJSON(data)
+	*e = JsxEmit(v)
+	return err
+}
+
+// NewLineKind mirrors ts.NewLineKind.
+type NewLineKind int
+
+const (
+	NewLineKindCarriageReturnLineFeed NewLineKind = 0
+	NewLineKindLineFeed               NewLineKind = 1
+)
+
+var newLineKindNames = enumNames{{0, "crlf"}, {1, "lf"}}
+
+func (k NewLineKind) String() string {
+	return newLineKindNames.format(int(k))
+}
+
+func (k NewLineKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *NewLineKind) UnmarshalText(text []byte) error {
+	v, err := newLineKindNames.parse(string(text))
+	*k = NewLineKind(v)
+	return err
+}
+
+func (k NewLineKind) MarshalJSON() ([]byte, error) {
+	return newLineKindNames.marshalJSON(int(k))
+}
+
+func (k *NewLineKind) UnmarshalJSON(data []byte) error {
+	v, err := newLineKindNames.unmarshalJSON(data)
+	*k = NewLineKind(v)
+	return err
+}
+
+// ModuleDetectionKind mirrors ts.ModuleDetectionKind.
+type ModuleDetectionKind int
+
+const (
+	ModuleDetectionKindLegacy ModuleDetectionKind = 1
+	ModuleDetectionKindAuto   ModuleDetectionKind = 2
+	ModuleDetectionKindForce  ModuleDetectionKind = 3
+)
+
+var moduleDetectionKindNames = enumNames{{1, "legacy"}, {2, "auto"}, {3, "force"}}
+
+func (k ModuleDetectionKind) String() string {
+	return moduleDetectionKindNames.format(int(k))
+}
+
+func (k ModuleDetectionKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+func (k *ModuleDetectionKind) UnmarshalText(text []byte) error {
+	v, err := moduleDetectionKindNames.parse(string(text))
+	*k = ModuleDetectionKind(v)
+	return err
+}
+
+func (k ModuleDetectionKind) MarshalJSON() ([]byte, error) {
+	return moduleDetectionKindNames.marshalJSON(int(k))
+}
+
+func (k *ModuleDetectionKind) UnmarshalJSON(data []byte) error {
+	v, err := moduleDetectionKindNames.unmarshalJSON(data)
+	*k = ModuleDetectionKind(v)
+	return err
+}
@@ -0,0 +1,139 @@
+package v8tsgo
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestCompilerOptionsEnums(t *testing.T) {
+	var target ScriptTarget
+	panicIfErr(target.UnmarshalText([]byte("ES6")))
+	test.AssertEqual(t, ScriptTargetES2015, target, "")
+	test.AssertEqual(t, "es2015", target.String(), "")
+	panicIfErr(json.Unmarshal([]byte(`99`), &target))
+	test.AssertEqual(t, ScriptTargetESNext, target, "")
+
+	var resolution ModuleResolutionKind
+	panicIfErr(json.Unmarshal([]byte(`"node"`), &resolution))
+	test.AssertEqual(t, ModuleResolutionKindNode10, resolution, "")
+	data, err := json.Marshal(resolution)
+	panicIfErr(err)
+	test.AssertEqual(t, `"node10"`, string(data), "")
+
+	// the values unknown to this package are kept
+	data, err = json.Marshal(ModuleKind(300))
+	panicIfErr(err)
+	test.AssertEqual(t, `300`, string(data), "")
+	var module ModuleKind
+	panicIfErr(json.Unmarshal(data, &module))
+	test.AssertEqual(t, ModuleKind(300), module, "")
+
+	var jsx JsxEmit
+	err = json.Unmarshal([]byte(`"react-dom"`), &jsx)
+	var enumErr *EnumValueError
+	test.MustEqual(t, true, errors.As(err, &enumErr), "")
+	test.AssertEqual(t, "'none', 'preserve', 'react', 'react-native', 'react-jsx', 'react-jsxdev'", enumErr.Allowed, "")
+}
+
+func TestCompilerOptionsJSON(t *testing.T) {
+	var options CompilerOptions
+	panicIfErr(json.Unmarshal([]byte(`{
+		"target": "ES2022",
+		"module": 199,
+		"moduleResolution": "nodenext",
+		"strict": true,
+		"noEmit": false,
+		"lib": ["dom", "es2022"],
+		"paths": {"@/*": ["src/*"]},
+		"maxNodeModuleJsDepth": 2,
+		"customOption": {"a": 1}
+	}`), &options))
+	test.AssertEqual(t, ScriptTargetES2022, *options.Target, "")
+	test.AssertEqual(t, ModuleKindNodeNext, *options.Module, "")
+	test.AssertEqual(t, ModuleResolutionKindNodeNext, *options.ModuleResolution, "")
+	test.AssertEqual(t, true, *options.Strict, "")
+	test.AssertEqual(t, false, *options.NoEmit, "")
+	test.AssertEqual(t, "src/*", options.Paths["@/*"][0], "")
+	test.AssertEqual(t, 2, *options.MaxNodeModuleJsDepth, "")
+	test.AssertEqual(t, float64(1), options.Extra["customOption"].(map[string]any)["a"].(float64), "")
+
+	data, err := json.Marshal(options)
+	panicIfErr(err)
+	test.AssertEqual(t, `{"customOption":{"a":1},"lib":["dom","es2022"],"maxNodeModuleJsDepth":2,"module":"nodenext","moduleResolution":"nodenext","noEmit":false,"paths":{"@/*":["src/*"]},"strict":true,"target":"es2022"}`, string(data), "")
+
+	err = json.Unmarshal([]byte(`{"strict": "yes"}`), &options)
+	test.AssertEqual(t, true, err != nil, "")
+}
+
+func TestCompilerOptionsAPI(t *testing.T) {
+	options := CompilerOptions{
+		Target:          Ptr(ScriptTargetES2020),
+		Module:          Ptr(ModuleKindCommonJS),
+		Jsx:             Ptr(JsxEmitReactJSX),
+		Lib:             []string{"ES6", "dom.iterable"},
+		Strict:          Ptr(true),
+		SkipLibCheck:    Ptr(false),
+		OutDir:          "/out",
+		ModuleDetection: Ptr(ModuleDetectionKindForce),
+		Extra:           map[string]any{"customOption": "x"},
+	}
+	api := options.ToAPI()
+	test.AssertEqual(t, 7, api["target"].(int), "")
+	test.AssertEqual(t, 1, api["module"].(int), "")
+	test.AssertEqual(t, 4, api["jsx"].(int), "")
+	test.AssertEqual(t, 3, api["moduleDetection"].(int), "")
+	test.AssertEqual(t, "lib.es2015.d.ts,lib.dom.iterable.d.ts", strings.Join(api["lib"].([]string), ","), "")
+	test.AssertEqual(t, true, api["strict"].(bool), "")
+	test.AssertEqual(t, false, api["skipLibCheck"].(bool), "")
+	test.AssertEqual(t, "/out", api["outDir"].(string), "")
+	test.AssertEqual(t, "x", api["customOption"].(string), "")
+	_, ok := api["moduleResolution"]
+	test.AssertEqual(t, false, ok, "")
+
+	back, err := CompilerOptionsFromAPI(api)
+	panicIfErr(err)
+	test.AssertEqual(t, ScriptTargetES2020, *back.Target, "")
+	test.AssertEqual(t, JsxEmitReactJSX, *back.Jsx, "")
+	test.AssertEqual(t, "es2015,dom.iterable", strings.Join(back.Lib, ","), "")
+	test.AssertEqual(t, false, *back.SkipLibCheck, "")
+	test.AssertEqual(t, "x", back.Extra["customOption"].(string), "")
+
+	// the options read back from the javascript side are float64
+	back, err = CompilerOptionsFromAPI(map[string]any{"target": float64(99), "lib": []any{"lib.esnext.d.ts"}})
+	panicIfErr(err)
+	test.AssertEqual(t, ScriptTargetESNext, *back.Target, "")
+	test.AssertEqual(t, "esnext", back.Lib[0], "")
+
+	_, err = CompilerOptionsFromAPI(map[string]any{"module": "amd2"})
+	test.AssertEqual(t, true, err != nil, "")
+}
+
+func TestCompilerOptionsTranspile(t *testing.T) {
+	c, _ := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "const a: number = 1;",
+	})
+	options := CompilerOptions{Target: Ptr(ScriptTargetES2020), SourceMap: Ptr(true), Lib: []string{"dom"}}
+	out, err := c.Transpile("/src/a.ts", options.ToAPI())
+	panicIfErr(err)
+	test.AssertEqual(t, `{"version":3}`, out.SourceMapText, "")
+}
+
+func TestDecodeCompilerOptions(t *testing.T) {
+	options, diagnostics := decodeCompilerOptions("/tsconfig.json", map[string]any{
+		"target":  "es2099",
+		"module":  "esnext",
+		"newLine": "LF",
+		"types":   "node",
+	})
+	test.AssertEqual(t, strings.Join([]string{
+		"/tsconfig.json: error TS6046: Argument for '--target' option must be: 'es3', 'es5', 'es2015', 'es6', 'es2016', 'es2017', 'es2018', 'es2019', 'es2020', 'es2021', 'es2022', 'es2023', 'esnext'.",
+		"/tsconfig.json: error TS5024: Compiler option 'types' requires a value of type Array.",
+	}, "\n"), diagnosticsString(diagnostics), "")
+	test.AssertEqual(t, true, options.Target == nil, "")
+	test.AssertEqual(t, ModuleKindESNext, *options.Module, "")
+	test.AssertEqual(t, NewLineKindLineFeed, *options.NewLine, "")
+}
@@ -0,0 +1,572 @@
+package v8tsgo
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"rogchap.com/v8go"
+)
+
+var (
+	timeType            = reflect.TypeOf(time.Time{})
+	bigIntType          = reflect.TypeOf(big.Int{})
+	jsonUnmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// DecodeError is returned by ParseValue when the value does not fit the go type.
+type DecodeError struct {
+	// Path locates the value in the decoded one, such as "a.b[2]", it is empty for the value itself.
+	Path    string
+	Message string
+	Err     error
+}
+
+func (e *DecodeError) Error() string {
+	msg := e.Message
+	if e.Err != nil {
+		msg += ", " + e.Err.Error()
+	}
+	if e.Path == "" {
+		return msg
+	}
+	return fmt.Sprintf("field \"%s\": %s", e.Path, msg)
+}
+
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+func fieldPath(parent string, name string) string {
+	if parent == "" {
+		return name
+	}
+	return parent + "." + name
+}
+
+func indexPath(parent string, i int) string {
+	return fmt.Sprintf("%s[%d]", parent, i)
+}
+
+type decoder struct {
+	ctx *v8go.Context
+	// depth counts the values being decoded, so a cycle fails instead of recursing forever
+	depth int
+}
+
+// enter fails once the values are nested deeper than maxValueDepth as makeReflectValue does,
+// the caller must call leave when it succeeds.
+func (d *decoder) enter(path string) error {
+	if d.depth >= maxValueDepth {
+		return d.errorf(path, "the value is nested too deeply, it may contain a cycle")
+	}
+	d.depth++
+	return nil
+}
+
+func (d *decoder) leave() {
+	d.depth--
+}
+
+// the numbers beyond ±(2^53 - 1) may have lost precision, a bigint is needed to pass them exactly
+const maxSafeInteger = 1<<53 - 1
+
+// integer returns the integer value of a bigint, or of a number which is a safe integer
+func (d *decoder) integer(value *v8go.Value, path string) (*big.Int, error) {
+	if value.IsBigInt() {
+		return value.BigInt(), nil
+	}
+	if !value.IsNumber() {
+		return nil, d.errorf(path, "expected number")
+	}
+	f := value.Number()
+	if f != math.Trunc(f) {
+		return nil, d.errorf(path, "expected integer, but got %v", f)
+	}
+	if math.Abs(f) > maxSafeInteger {
+		return nil, d.errorf(path, "the number %v is not a safe integer, use a bigint instead", f)
+	}
+	return big.NewInt(int64(f)), nil
+}
+
+func (d *decoder) errorf(path string, format string, args ...any) error {
+	return &DecodeError{Path: path, Message: fmt.Sprintf(format, args...)}
+}
+
+func (d *decoder) wrap(path string, message string, err error) error {
+	return &DecodeError{Path: path, Message: message, Err: err}
+}
+
+// callGlobal calls a static method of a global constructor, such as Object.keys
+func (d *decoder) callGlobal(ctor string, method string, args ...v8go.Valuer) (*v8go.Value, error) {
+	valCtor, err := d.ctx.Global().Get(ctor)
+	if err != nil {
+		return nil, err
+	}
+	objCtor, err := valCtor.AsObject()
+	if err != nil {
+		return nil, err
+	}
+	return objCtor.MethodCall(method, args...)
+}
+
+// elements returns the elements of an array, a set or a typed array
+func (d *decoder) elements(value *v8go.Value) ([]*v8go.Value, error) {
+	if !value.IsArray() {
+		var err error
+		value, err = d.callGlobal("Array", "from", value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return arrayElements(value)
+}
+
+func isListValue(value *v8go.Value) bool {
+	return value.IsArray() || value.IsSet() || value.IsTypedArray()
+}
+
+type entry struct {
+	key   *v8go.Value
+	value *v8go.Value
+}
+
+// entries returns the entries of a Map, or the own enumerable properties of an object
+func (d *decoder) entries(value *v8go.Value) ([]entry, error) {
+	if value.IsMap() {
+		pairs, err := d.elements(value)
+		if err != nil {
+			return nil, err
+		}
+		entries := make([]entry, 0, len(pairs))
+		for _, pair := range pairs {
+			kv, err := arrayElements(pair)
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry{kv[0], kv[1]})
+		}
+		return entries, nil
+	}
+	keys, err := d.callGlobal("Object", "keys", value)
+	if err != nil {
+		return nil, err
+	}
+	keyValues, err := arrayElements(keys)
+	if err != nil {
+		return nil, err
+	}
+	obj := value.Object()
+	entries := make([]entry, 0, len(keyValues))
+	for _, key := range keyValues {
+		v, err := objectGet(obj, key.String())
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry{key, v})
+	}
+	return entries, nil
+}
+
+// decodeJSON decodes the value with encoding/json, it is used for the json.Unmarshaler implementations
+func (d *decoder) decodeJSON(value *v8go.Value, rv reflect.Value, path string) error {
+	js, err := v8go.JSONStringify(d.ctx, value)
+	if err != nil {
+		return d.wrap(path, "unable to json stringify the value", err)
+	}
+	err = json.Unmarshal([]byte(js), rv.Addr().Interface())
+	if err != nil {
+		return d.wrap(path, "unable to unmarshal the json", err)
+	}
+	return nil
+}
+
+// decode sets the settable rv from the value, null and undefined leave it unchanged unless it is a pointer,
+// a map or a slice, which become nil as encoding/json does.
+func (d *decoder) decode(value *v8go.Value, rv reflect.Value, path string) error {
+	err := d.enter(path)
+	if err != nil {
+		return err
+	}
+	defer d.leave()
+	if value.IsNullOrUndefined() {
+		switch rv.Kind() {
+		case reflect.Pointer, reflect.Map, reflect.Slice, reflect.Interface:
+			rv.Set(reflect.Zero(rv.Type()))
+		}
+		return nil
+	}
+	if rv.Type() == timeType {
+		if value.IsDate() {
+			ts, err := dateGetTime(value)
+			if err != nil {
+				return d.wrap(path, "failed to get the timestamp from the date", err)
+			}
+			rv.Set(reflect.ValueOf(time.UnixMilli(ts)))
+			return nil
+		}
+		if !value.IsString() {
+			return d.errorf(path, "expected date")
+		}
+	}
+	if rv.Kind() == reflect.Pointer {
+		if rv.IsNil() {
+			rv.Set(reflect.New(rv.Type().Elem()))
+		}
+		return d.decode(value, rv.Elem(), path)
+	}
+	if rv.Type() == bigIntType {
+		return d.decodeBigInt(value, rv, path)
+	}
+	if rv.CanAddr() {
+		ptr := rv.Addr().Type()
+		if value.IsString() && ptr.Implements(textUnmarshalerType) {
+			err := rv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(value.String()))
+			if err != nil {
+				return d.wrap(path, "unable to unmarshal the text", err)
+			}
+			return nil
+		}
+		if ptr.Implements(jsonUnmarshalerType) {
+			return d.decodeJSON(value, rv, path)
+		}
+	}
+	switch rv.Kind() {
+	case reflect.Interface:
+		if rv.NumMethod() != 0 {
+			return d.errorf(path, "unable to decode into the non-empty interface %s", rv.Type())
+		}
+		v, err := d.decodeAny(value, path)
+		if err != nil {
+			return err
+		}
+		if v == nil {
+			rv.Set(reflect.Zero(rv.Type()))
+		} else {
+			rv.Set(reflect.ValueOf(v))
+		}
+	case reflect.Bool:
+		if !value.IsBoolean() {
+			return d.errorf(path, "expected boolean")
+		}
+		rv.SetBool(value.Boolean())
+	case reflect.String:
+		if !value.IsString() && !value.IsStringObject() {
+			return d.errorf(path, "expected string")
+		}
+		rv.SetString(value.String())
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := d.integer(value, path)
+		if err != nil {
+			return err
+		}
+		if !n.IsInt64() || rv.OverflowInt(n.Int64()) {
+			return d.errorf(path, "the integer %s overflows %s", n, rv.Type())
+		}
+		rv.SetInt(n.Int64())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := d.integer(value, path)
+		if err != nil {
+			return err
+		}
+		if !n.IsUint64() || rv.OverflowUint(n.Uint64()) {
+			return d.errorf(path, "the integer %s overflows %s", n, rv.Type())
+		}
+		rv.SetUint(n.Uint64())
+	case reflect.Float32, reflect.Float64:
+		if !value.IsNumber() {
+			return d.errorf(path, "expected number")
+		}
+		f := value.Number()
+		if rv.OverflowFloat(f) {
+			return d.errorf(path, "the number %v overflows %s", f, rv.Type())
+		}
+		rv.SetFloat(f)
+	case reflect.Struct:
+		return d.decodeStruct(value, rv, path)
+	case reflect.Map:
+		return d.decodeMap(value, rv, path)
+	case reflect.Slice:
+		if rv.Type().Elem().Kind() == reflect.Uint8 && value.IsString() {
+			// MakeValue encodes []byte to base64 as encoding/json does
+			b, err := base64.StdEncoding.DecodeString(value.String())
+			if err != nil {
+				return d.wrap(path, "unable to decode the base64 string", err)
+			}
+			rv.SetBytes(b)
+			return nil
+		}
+		if !isListValue(value) {
+			return d.errorf(path, "expected array")
+		}
+		elements, err := d.elements(value)
+		if err != nil {
+			return d.wrap(path, "unable to access the elements", err)
+		}
+		out := reflect.MakeSlice(rv.Type(), len(elements), len(elements))
+		for i, element := range elements {
+			err = d.decode(element, out.Index(i), indexPath(path, i))
+			if err != nil {
+				return err
+			}
+		}
+		rv.Set(out)
+	case reflect.Array:
+		if !isListValue(value) {
+			return d.errorf(path, "expected array")
+		}
+		elements, err := d.elements(value)
+		if err != nil {
+			return d.wrap(path, "unable to access the elements", err)
+		}
+		for i := 0; i < rv.Len(); i++ {
+			if i >= len(elements) {
+				rv.Index(i).Set(reflect.Zero(rv.Type().Elem()))
+				continue
+			}
+			err = d.decode(elements[i], rv.Index(i), indexPath(path, i))
+			if err != nil {
+				return err
+			}
+		}
+	default:
+		return d.errorf(path, "unsupported type %s", rv.Type())
+	}
+	return nil
+}
+
+// decodeBigInt accepts a bigint, a safe integer number or a decimal string, as big.Int.UnmarshalJSON does
+func (d *decoder) decodeBigInt(value *v8go.Value, rv reflect.Value, path string) error {
+	if value.IsString() {
+		n, ok := new(big.Int).SetString(value.String(), 10)
+		if !ok {
+			return d.errorf(path, "invalid integer \"%s\"", value.String())
+		}
+		rv.Set(reflect.ValueOf(n).Elem())
+		return nil
+	}
+	if !value.IsBigInt() && !value.IsNumber() {
+		return d.errorf(path, "expected bigint")
+	}
+	n, err := d.integer(value, path)
+	if err != nil {
+		return err
+	}
+	rv.Set(reflect.ValueOf(n).Elem())
+	return nil
+}
+
+func (d *decoder) decodeStruct(value *v8go.Value, rv reflect.Value, path string) error {
+	if !value.IsObject() || isListValue(value) || value.IsMap() {
+		return d.errorf(path, "expected object")
+	}
+	obj := value.Object()
+	fields := structFields(rv.Type())
+	var folded map[string]string
+	for _, f := range fields {
+		key := f.name
+		if !obj.Has(key) {
+			if folded == nil {
+				var err error
+				folded, err = d.foldedKeys(value, fields)
+				if err != nil {
+					return d.wrap(path, "unable to access the keys", err)
+				}
+			}
+			var ok bool
+			key, ok = folded[strings.ToLower(f.name)]
+			if !ok {
+				continue
+			}
+		}
+		fv, err := objectGet(obj, key)
+		if err != nil {
+			return d.wrap(fieldPath(path, f.name), "unable to access the field", err)
+		}
+		if fv.IsUndefined() {
+			continue
+		}
+		field, ok := settableFieldByIndex(rv, f.index)
+		if !ok {
+			continue
+		}
+		err = d.decode(fv, field, fieldPath(path, f.name))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// foldedKeys maps the lower case form of the own enumerable keys, which don't name a field exactly,
+// to the first of these keys, so the fields accept a case-insensitive match as encoding/json does.
+func (d *decoder) foldedKeys(value *v8go.Value, fields []structField) (map[string]string, error) {
+	keys, err := d.callGlobal("Object", "keys", value)
+	if err != nil {
+		return nil, err
+	}
+	keyValues, err := arrayElements(keys)
+	if err != nil {
+		return nil, err
+	}
+	exact := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		exact[f.name] = true
+	}
+	folded := make(map[string]string, len(keyValues))
+	for _, kv := range keyValues {
+		key := kv.String()
+		lower := strings.ToLower(key)
+		if _, ok := folded[lower]; !ok && !exact[key] {
+			folded[lower] = key
+		}
+	}
+	return folded, nil
+}
+
+// settableFieldByIndex allocates the nil embedded pointers on the way,
+// it reports false if one of them is unexported and can't be allocated.
+func settableFieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				if !v.CanSet() {
+					return reflect.Value{}, false
+				}
+				v.Set(reflect.New(v.Type().Elem()))
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func (d *decoder) decodeMapKey(key *v8go.Value, t reflect.Type, path string) (reflect.Value, error) {
+	kv := reflect.New(t).Elem()
+	if !key.IsString() {
+		return kv, d.decode(key, kv, path)
+	}
+	s := key.String()
+	if reflect.PointerTo(t).Implements(textUnmarshalerType) {
+		err := kv.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+		if err != nil {
+			return kv, d.wrap(path, "unable to unmarshal the key", err)
+		}
+		return kv, nil
+	}
+	switch t.Kind() {
+	case reflect.String:
+		kv.SetString(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, t.Bits())
+		if err != nil {
+			return kv, d.wrap(path, "invalid key", err)
+		}
+		kv.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		n, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return kv, d.wrap(path, "invalid key", err)
+		}
+		kv.SetUint(n)
+	default:
+		return kv, d.errorf(path, "unsupported map key type %s", t)
+	}
+	return kv, nil
+}
+
+func (d *decoder) decodeMap(value *v8go.Value, rv reflect.Value, path string) error {
+	if !value.IsObject() || isListValue(value) {
+		return d.errorf(path, "expected object")
+	}
+	entries, err := d.entries(value)
+	if err != nil {
+		return d.wrap(path, "unable to access the entries", err)
+	}
+	if rv.IsNil() {
+		rv.Set(reflect.MakeMapWithSize(rv.Type(), len(entries)))
+	}
+	t := rv.Type()
+	for _, e := range entries {
+		entryPath := fieldPath(path, e.key.String())
+		key, err := d.decodeMapKey(e.key, t.Key(), entryPath)
+		if err != nil {
+			return err
+		}
+		// undefined members are kept as the zero value
+		elem := reflect.New(t.Elem()).Elem()
+		err = d.decode(e.value, elem, entryPath)
+		if err != nil {
+			return err
+		}
+		rv.SetMapIndex(key, elem)
+	}
+	return nil
+}
+
+// decodeAny decodes the value to the types encoding/json uses for any, except that
+// dates become time.Time, bigints *big.Int, maps map[string]any and sets and typed arrays []any.
+func (d *decoder) decodeAny(value *v8go.Value, path string) (any, error) {
+	err := d.enter(path)
+	if err != nil {
+		return nil, err
+	}
+	defer d.leave()
+	switch {
+	case value.IsNullOrUndefined():
+		return nil, nil
+	case value.IsBoolean():
+		return value.Boolean(), nil
+	case value.IsString() || value.IsStringObject():
+		return value.String(), nil
+	case value.IsNumber():
+		return value.Number(), nil
+	case value.IsBigInt():
+		return value.BigInt(), nil
+	case value.IsDate():
+		ts, err := dateGetTime(value)
+		if err != nil {
+			return nil, d.wrap(path, "failed to get the timestamp from the date", err)
+		}
+		return time.UnixMilli(ts), nil
+	case isListValue(value):
+		elements, err := d.elements(value)
+		if err != nil {
+			return nil, d.wrap(path, "unable to access the elements", err)
+		}
+		out := make([]any, len(elements))
+		for i, element := range elements {
+			out[i], err = d.decodeAny(element, indexPath(path, i))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	case value.IsFunction() || value.IsSymbol():
+		return nil, d.errorf(path, "unable to decode a %s", value.DetailString())
+	case value.IsObject():
+		entries, err := d.entries(value)
+		if err != nil {
+			return nil, d.wrap(path, "unable to access the entries", err)
+		}
+		out := make(map[string]any, len(entries))
+		for _, e := range entries {
+			key := e.key.String()
+			out[key], err = d.decodeAny(e.value, fieldPath(path, key))
+			if err != nil {
+				return nil, err
+			}
+		}
+		return out, nil
+	}
+	return nil, d.errorf(path, "unsupported value %s", value.DetailString())
+}
@@ -0,0 +1,202 @@
+package v8tsgo
+
+import (
+	"fmt"
+	"strings"
+
+	v8 "rogchap.com/v8go"
+)
+
+type DiagnosticCategory int
+
+const (
+	DiagnosticCategoryWarning DiagnosticCategory = iota
+	DiagnosticCategoryError
+	DiagnosticCategorySuggestion
+	DiagnosticCategoryMessage
+)
+
+func (c DiagnosticCategory) String() string {
+	switch c {
+	case DiagnosticCategoryWarning:
+		return "warning"
+	case DiagnosticCategoryError:
+		return "error"
+	case DiagnosticCategorySuggestion:
+		return "suggestion"
+	case DiagnosticCategoryMessage:
+		return "message"
+	default:
+		return fmt.Sprintf("category(%d)", int(c))
+	}
+}
+
+// DiagnosticMessageChain mirrors ts.DiagnosticMessageChain.
+type DiagnosticMessageChain struct {
+	MessageText string                   `json:"messageText"`
+	Category    DiagnosticCategory       `json:"category"`
+	Code        int                      `json:"code"`
+	Next        []DiagnosticMessageChain `json:"next,omitempty"`
+}
+
+type Diagnostic struct {
+	// File is empty for the global diagnostics, such as the option errors.
+	File   string `json:"file"`
+	Start  int    `json:"start"`
+	Length int    `json:"length"`
+	// Line and Column are 1-based, both are 0 when the diagnostic is not attached to a file.
+	Line     int                `json:"line"`
+	Column   int                `json:"column"`
+	Code     int                `json:"code"`
+	Category DiagnosticCategory `json:"category"`
+	// Message is the flattened message text, lines of the nested messages are indented by two spaces per depth.
+	Message string `json:"message"`
+	// MessageChain is nil when the message is a plain string.
+	MessageChain       *DiagnosticMessageChain `json:"messageChain,omitempty"`
+	RelatedInformation []Diagnostic            `json:"relatedInformation,omitempty"`
+}
+
+// String formats the diagnostic like tsc does without --pretty, e.g.
+//
+//	src/a.ts(1,7): error TS2322: Type 'string' is not assignable to type 'number'.
+func (d Diagnostic) String() string {
+	var sb strings.Builder
+	if d.File != "" {
+		sb.WriteString(d.File)
+		if d.Line > 0 {
+			fmt.Fprintf(&sb, "(%d,%d)", d.Line, d.Column)
+		}
+		sb.WriteString(": ")
+	}
+	fmt.Fprintf(&sb, "%s TS%d: %s", d.Category, d.Code, d.Message)
+	return sb.String()
+}
+
+// HasErrors reports whether any of the diagnostics is an error.
+func HasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Category == DiagnosticCategoryError {
+			return true
+		}
+	}
+	return false
+}
+
+func decodeMessageChain(value *v8.Value) (*DiagnosticMessageChain, error) {
+	if value.IsNullOrUndefined() {
+		return nil, nil
+	}
+	obj, err := value.AsObject()
+	if err != nil {
+		return nil, fmt.Errorf("the message chain is not an object, %w", err)
+	}
+	var chain DiagnosticMessageChain
+	chain.MessageText, err = objectGetString(obj, "messageText")
+	if err != nil {
+		return nil, err
+	}
+	category, err := objectGetInt(obj, "category")
+	if err != nil {
+		return nil, err
+	}
+	chain.Category = DiagnosticCategory(category)
+	chain.Code, err = objectGetInt(obj, "code")
+	if err != nil {
+		return nil, err
+	}
+	valNext, err := objectGet(obj, "next")
+	if err != nil {
+		return nil, err
+	}
+	if !valNext.IsNullOrUndefined() {
+		elements, err := arrayElements(valNext)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the next message chains, %w", err)
+		}
+		for _, element := range elements {
+			next, err := decodeMessageChain(element)
+			if err != nil {
+				return nil, err
+			}
+			if next != nil {
+				chain.Next = append(chain.Next, *next)
+			}
+		}
+	}
+	return &chain, nil
+}
+
+func decodeDiagnostic(value *v8.Value) (Diagnostic, error) {
+	var d Diagnostic
+	obj, err := value.AsObject()
+	if err != nil {
+		return d, fmt.Errorf("the diagnostic is not an object, %w", err)
+	}
+	if d.File, err = objectGetString(obj, "file"); err != nil {
+		return d, err
+	}
+	if d.Start, err = objectGetInt(obj, "start"); err != nil {
+		return d, err
+	}
+	if d.Length, err = objectGetInt(obj, "length"); err != nil {
+		return d, err
+	}
+	if d.Line, err = objectGetInt(obj, "line"); err != nil {
+		return d, err
+	}
+	if d.Column, err = objectGetInt(obj, "column"); err != nil {
+		return d, err
+	}
+	if d.Code, err = objectGetInt(obj, "code"); err != nil {
+		return d, err
+	}
+	category, err := objectGetInt(obj, "category")
+	if err != nil {
+		return d, err
+	}
+	d.Category = DiagnosticCategory(category)
+	if d.Message, err = objectGetString(obj, "message"); err != nil {
+		return d, err
+	}
+	valChain, err := objectGet(obj, "messageChain")
+	if err != nil {
+		return d, err
+	}
+	if d.MessageChain, err = decodeMessageChain(valChain); err != nil {
+		return d, err
+	}
+	valRelated, err := objectGet(obj, "relatedInformation")
+	if err != nil {
+		return d, err
+	}
+	if !valRelated.IsNullOrUndefined() {
+		if d.RelatedInformation, err = decodeDiagnostics(valRelated); err != nil {
+			return d, fmt.Errorf("unable to decode the related information, %w", err)
+		}
+	}
+	return d, nil
+}
+
+func decodeDiagnostics(value *v8.Value) ([]Diagnostic, error) {
+	elements, err := arrayElements(value)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the diagnostics, %w", err)
+	}
+	diagnostics := make([]Diagnostic, 0, len(elements))
+	for i, element := range elements {
+		d, err := decodeDiagnostic(element)
+		if err != nil {
+			return nil, fmt.Errorf("unable to decode the diagnostic %d, %w", i, err)
+		}
+		diagnostics = append(diagnostics, d)
+	}
+	return diagnostics, nil
+}
+
+func decodeObjectDiagnostics(obj *v8.Object) ([]Diagnostic, error) {
+	value, err := objectGet(obj, "diagnostics")
+	if err != nil {
+		return nil, err
+	}
+	return decodeDiagnostics(value)
+}
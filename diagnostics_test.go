@@ -0,0 +1,52 @@
+package v8tsgo
+
+import (
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestTypeCheckDiagnostics(t *testing.T) {
+	c, _ := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "const a = 1;\n  // @error outer > inner > innermost @see declared here",
+	})
+	diagnostics, err := c.TypeCheck([]string{"/src/a.ts"}, nil)
+	panicIfErr(err)
+	test.MustEqual(t, 1, len(diagnostics), "")
+	d := diagnostics[0]
+	test.AssertEqual(t, "/src/a.ts", d.File, "")
+	test.AssertEqual(t, 2, d.Line, "")
+	test.AssertEqual(t, 6, d.Column, "")
+	test.AssertEqual(t, "outer\n  inner\n    innermost", d.Message, "")
+	test.MustEqual(t, true, d.MessageChain != nil, "")
+	test.AssertEqual(t, "outer", d.MessageChain.MessageText, "")
+	test.MustEqual(t, 1, len(d.MessageChain.Next), "")
+	test.AssertEqual(t, "inner", d.MessageChain.Next[0].MessageText, "")
+	test.MustEqual(t, 1, len(d.MessageChain.Next[0].Next), "")
+	test.AssertEqual(t, "innermost", d.MessageChain.Next[0].Next[0].MessageText, "")
+	test.AssertEqual(t, 0, len(d.MessageChain.Next[0].Next[0].Next), "")
+	test.MustEqual(t, 1, len(d.RelatedInformation), "")
+	test.AssertEqual(t, "declared here", d.RelatedInformation[0].Message, "")
+	test.AssertEqual(t, DiagnosticCategoryMessage, d.RelatedInformation[0].Category, "")
+	test.AssertEqual(t, 2, d.RelatedInformation[0].Line, "")
+	test.AssertEqual(t, true, d.RelatedInformation[0].MessageChain == nil, "")
+	test.AssertEqual(t, true, HasErrors(diagnostics), "")
+}
+
+func TestDiagnosticString(t *testing.T) {
+	d := Diagnostic{
+		File:     "src/a.ts",
+		Line:     1,
+		Column:   7,
+		Code:     2322,
+		Category: DiagnosticCategoryError,
+		Message:  "Type 'string' is not assignable to type 'number'.",
+	}
+	test.AssertEqual(t, "src/a.ts(1,7): error TS2322: Type 'string' is not assignable to type 'number'.", d.String(), "")
+	d = Diagnostic{
+		Code:     5023,
+		Category: DiagnosticCategoryWarning,
+		Message:  "Unknown compiler option.",
+	}
+	test.AssertEqual(t, "warning TS5023: Unknown compiler option.", d.String(), "")
+}
@@ -0,0 +1,180 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	v8 "rogchap.com/v8go"
+)
+
+// the js error codes of the go errors, following the node conventions
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{fs.ErrNotExist, "ENOENT"},
+	{fs.ErrExist, "EEXIST"},
+	{fs.ErrPermission, "EACCES"},
+	{filesystem.ErrNotDir, "ENOTDIR"},
+	{filesystem.ErrNotFile, "EISDIR"},
+	{fs.ErrInvalid, "EINVAL"},
+	{fs.ErrClosed, "EBADF"},
+	{context.DeadlineExceeded, "ETIMEDOUT"},
+	{context.Canceled, "ABORT_ERR"},
+	{errors.ErrUnsupported, "ENOSYS"},
+}
+
+// ErrorCode returns the js error code WrapError sets on the error, or "" if it has none.
+func ErrorCode(err error) string {
+	var ex *JSException
+	if errors.As(err, &ex) && ex.Code != "" {
+		return ex.Code
+	}
+	for _, c := range errorCodes {
+		if errors.Is(err, c.err) {
+			return c.code
+		}
+	}
+	return ""
+}
+
+func errorName(err error) string {
+	var ex *JSException
+	if errors.As(err, &ex) && ex.Name != "" {
+		return ex.Name
+	}
+	if errors.Is(err, context.Canceled) {
+		return "AbortError"
+	}
+	return "Error"
+}
+
+// JSException is a js exception converted to go.
+type JSException struct {
+	// Name is the name of the error, such as "TypeError", it is empty if the thrown value is not an error.
+	Name    string
+	Message string
+	// Code is the code property of the error, such as "ENOENT".
+	Code string
+	// Path is the path property of the file system errors.
+	Path  string
+	Stack string
+	// File, Line and Column locate where the exception was thrown, they are parsed from the stack.
+	File   string
+	Line   int
+	Column int
+	// Cause is the cause property of the error.
+	Cause error
+}
+
+func (e *JSException) Error() string {
+	if e.Name == "" {
+		return e.Message
+	}
+	return e.Name + ": " + e.Message
+}
+
+func (e *JSException) Unwrap() error {
+	return e.Cause
+}
+
+// Is matches the go errors of the code, so a js error with the code "ENOENT" is fs.ErrNotExist.
+func (e *JSException) Is(target error) bool {
+	if e.Code == "" {
+		return false
+	}
+	for _, c := range errorCodes {
+		if c.code == e.Code && c.err == target {
+			return true
+		}
+	}
+	return e.Code == "EPERM" && target == fs.ErrPermission
+}
+
+// matches "    at fn (file:1:2)" and "    at file:1:2"
+var stackFrameRegexp = regexp.MustCompile(`^\s+at (?:.* \()?(.+):(\d+):(\d+)\)?$`)
+
+func (e *JSException) parseLocation() {
+	for _, line := range strings.Split(e.Stack, "\n") {
+		m := stackFrameRegexp.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		e.File = m[1]
+		e.Line, _ = strconv.Atoi(m[2])
+		e.Column, _ = strconv.Atoi(m[3])
+		return
+	}
+}
+
+const maxCauseDepth = 32
+
+// NewJSException converts a thrown or rejected js value.
+func NewJSException(value *v8.Value) *JSException {
+	return newJSException(value, 0)
+}
+
+func newJSException(value *v8.Value, depth int) *JSException {
+	e := &JSException{}
+	if !value.IsObject() {
+		e.Message = value.DetailString()
+		return e
+	}
+	obj := value.Object()
+	if v, err := objectGet(obj, "message"); err == nil && !v.IsNullOrUndefined() {
+		e.Message = v.String()
+	} else {
+		e.Message = value.DetailString()
+	}
+	if v, err := objectGet(obj, "name"); err == nil && !v.IsNullOrUndefined() {
+		e.Name = v.String()
+	}
+	if v, err := objectGet(obj, "code"); err == nil && !v.IsNullOrUndefined() {
+		e.Code = v.String()
+	}
+	if v, err := objectGet(obj, "path"); err == nil && v.IsString() {
+		e.Path = v.String()
+	}
+	if v, err := objectGet(obj, "stack"); err == nil && !v.IsNullOrUndefined() {
+		e.Stack = v.String()
+		e.parseLocation()
+	}
+	if v, err := objectGet(obj, "cause"); err == nil && !v.IsUndefined() && depth < maxCauseDepth {
+		e.Cause = newJSException(v, depth+1)
+	}
+	return e
+}
+
+// AsJSException converts the *v8.JSError returned by the v8go apis to a *JSException,
+// the other errors are returned as is. v8go only keeps the string form of the exception,
+// so the code and the cause are lost.
+func AsJSException(err error) error {
+	var jsErr *v8.JSError
+	if !errors.As(err, &jsErr) {
+		return err
+	}
+	e := &JSException{
+		Message: jsErr.Message,
+		Stack:   jsErr.StackTrace,
+	}
+	if name, message, ok := strings.Cut(jsErr.Message, ": "); ok && !strings.ContainsAny(name, " \n") {
+		e.Name = name
+		e.Message = message
+	}
+	e.parseLocation()
+	if e.File == "" && jsErr.Location != "" {
+		// compile errors have no stack, only a location like "file:1:2"
+		parts := strings.Split(jsErr.Location, ":")
+		if len(parts) >= 3 {
+			e.File = strings.Join(parts[:len(parts)-2], ":")
+			e.Line, _ = strconv.Atoi(parts[len(parts)-2])
+			e.Column, _ = strconv.Atoi(parts[len(parts)-1])
+		}
+	}
+	return e
+}
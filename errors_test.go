@@ -0,0 +1,99 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	v8 "rogchap.com/v8go"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestWrapError(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	pathErr := &fs.PathError{Op: "open", Path: "/a.txt", Err: fs.ErrNotExist}
+	valErr, err := rt.Utils().WrapError(fmt.Errorf("unable to read, %w", pathErr))
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("err", valErr))
+	res, err := ctx.RunScript(`[
+		err instanceof Error, err.name, err.code, err.path, err.syscall,
+		err.cause.message, err.cause.code, err.cause.cause.message, err.cause.cause.cause === undefined,
+	].join('|')`, "wrap.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "true|Error|ENOENT|/a.txt|open|open /a.txt: file does not exist|ENOENT|file does not exist|true", res.String(), "")
+
+	valErr, err = rt.Utils().WrapError(context.Canceled)
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("err", valErr))
+	res, err = ctx.RunScript("err.name + '|' + err.code", "wrap.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "AbortError|ABORT_ERR", res.String(), "")
+}
+
+func TestJSException(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	res, err := ctx.RunScript(`
+		function fail() {
+			const e = new TypeError('bad file', { cause: 'disk' });
+			e.code = 'ENOENT';
+			return e;
+		}
+		fail()
+	`, "throw.js")
+	panicIfErr(err)
+	ex := NewJSException(res)
+	test.AssertEqual(t, "TypeError", ex.Name, "")
+	test.AssertEqual(t, "bad file", ex.Message, "")
+	test.AssertEqual(t, "TypeError: bad file", ex.Error(), "")
+	test.AssertEqual(t, "ENOENT", ex.Code, "")
+	test.AssertEqual(t, "throw.js", ex.File, "")
+	test.AssertEqual(t, 3, ex.Line, "")
+	test.AssertEqual(t, 14, ex.Column, "")
+	test.AssertEqual(t, true, errors.Is(ex, fs.ErrNotExist), "")
+	test.MustEqual(t, true, ex.Cause != nil, "")
+	test.AssertEqual(t, "disk", ex.Cause.Error(), "")
+
+	_, err = ctx.RunScript("\n  null.foo", "null.js")
+	err = AsJSException(err)
+	test.MustEqual(t, true, errors.As(err, &ex), "")
+	test.AssertEqual(t, "TypeError", ex.Name, "")
+	test.AssertEqual(t, "null.js", ex.File, "")
+	test.AssertEqual(t, 2, ex.Line, "")
+}
+
+func TestErrorRoundTrip(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	ctx := rt.Context()
+
+	valErr, err := rt.Utils().WrapError(&JSException{Name: "RangeError", Message: "too big", Code: "ERR_OUT_OF_RANGE"})
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("err", valErr))
+	promise, err := ctx.RunScript("Promise.reject(err)", "reject.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	var ex *JSException
+	test.MustEqual(t, true, errors.As(err, &ex), "")
+	test.AssertEqual(t, "RangeError", ex.Name, "")
+	test.AssertEqual(t, "too big", ex.Message, "")
+	test.AssertEqual(t, "ERR_OUT_OF_RANGE", ex.Code, "")
+	test.AssertEqual(t, "ERR_OUT_OF_RANGE", ErrorCode(err), "")
+
+	valErr, err = rt.Utils().WrapError(fs.ErrPermission)
+	panicIfErr(err)
+	test.AssertEqual(t, true, errors.Is(NewJSException(valErr), fs.ErrPermission), "")
+	test.AssertEqual(t, false, errors.Is(NewJSException(v8.Undefined(ctx.Isolate())), fs.ErrPermission), "")
+
+	valErr, err = rt.Utils().WrapError(filesystem.NewNotDir("/a.ts"))
+	panicIfErr(err)
+	ex = NewJSException(valErr)
+	test.AssertEqual(t, "ENOTDIR", ex.Code, "")
+	test.AssertEqual(t, true, errors.Is(ex, filesystem.ErrNotDir), "")
+}
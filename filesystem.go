@@ -1,10 +1,12 @@
 package v8tsgo
 
 import (
+	"errors"
 	"fmt"
 	"io/fs"
+	"sync"
 
-	"github.com/vipcxj/v8tsgo/internal/filesystem"
+	"github.com/vipcxj/v8tsgo/filesystem"
 	v8 "rogchap.com/v8go"
 )
 
@@ -12,6 +14,11 @@ type V8FileSystemHost struct {
 	ctx   *v8.Context
 	utils *V8Utils
 
+	// the stop funcs of the watchers not closed yet, stopped by Close
+	watchMu     sync.Mutex
+	watchers    map[int]func()
+	nextWatcher int
+
 	// Gets if this file system is case sensitive.
 	//   isCaseSensitive(): boolean
 	fnIsCaseSensitive *v8.FunctionTemplate
@@ -25,16 +32,26 @@ type V8FileSystemHost struct {
 
 	// Reads all the child directories and files.
 	// Implementers should have this return the full file path.
+	// Implementers should throw an `errors.DirectoryNotFoundError` when it does not exist.
 	//   readDirSync(dirPath: string): RuntimeDirEntry[]
 	fnReadDirSync *v8.FunctionTemplate
 
 	// Asynchronously reads a file at the specified path.
+	// Implementers should throw an `errors.FileNotFoundError` when it does not exist.
 	//	 readFile(filePath: string, encoding?: string): Promise<string>
 	fnReadFile *v8.FunctionTemplate
 	// Synchronously reads a file at the specified path.
+	// Implementers should throw an `errors.FileNotFoundError` when it does not exist.
 	//	 readFileSync(filePath: string, encoding?: string): string
 	fnReadFileSync *v8.FunctionTemplate
 
+	// Asynchronously reads the bytes of a file at the specified path.
+	//   readFileBytes(filePath: string): Promise<Uint8Array>
+	fnReadFileBytes *v8.FunctionTemplate
+	// Synchronously reads the bytes of a file at the specified path.
+	//   readFileBytesSync(filePath: string): Uint8Array
+	fnReadFileBytesSync *v8.FunctionTemplate
+
 	// Asynchronously writes a file to the file system.
 	//   writeFile(filePath: string, fileText: string): Promise<void>
 	fnWriteFile *v8.FunctionTemplate
@@ -42,6 +59,13 @@ type V8FileSystemHost struct {
 	//   writeFileSync(filePath: string, fileText: string): void
 	fnWriteFileSync *v8.FunctionTemplate
 
+	// Asynchronously writes the bytes of a file to the file system.
+	//   writeFileBytes(filePath: string, data: ArrayBuffer | ArrayBufferView): Promise<void>
+	fnWriteFileBytes *v8.FunctionTemplate
+	// Synchronously writes the bytes of a file to the file system.
+	//   writeFileBytesSync(filePath: string, data: ArrayBuffer | ArrayBufferView): void
+	fnWriteFileBytesSync *v8.FunctionTemplate
+
 	// Asynchronously creates a directory at the specified path.
 	//   mkdir(dirPath: string): Promise<void>
 	fnMkdir *v8.FunctionTemplate
@@ -64,11 +88,9 @@ type V8FileSystemHost struct {
 	fnCopySync *v8.FunctionTemplate
 
 	// Asynchronously checks if a file exists.
-	// Implementers should throw an `errors.FileNotFoundError` when it does not exist.
 	//   fileExists(filePath: string): Promise<boolean>
 	fnFileExists *v8.FunctionTemplate
 	// Synchronously checks if a file exists.
-	// Implementers should throw an `errors.FileNotFoundError` when it does not exist.
 	//   fileExistsSync(filePath: string): boolean
 	fnFileExistsSync *v8.FunctionTemplate
 
@@ -79,6 +101,16 @@ type V8FileSystemHost struct {
 	//   directoryExistsSync(dirPath: string): boolean
 	fnDirectoryExistsSync *v8.FunctionTemplate
 
+	// Asynchronously gets the stats of the file or the directory, following the symbolic links.
+	//   stat(path: string): Promise<RuntimeStats>
+	fnStat *v8.FunctionTemplate
+	// Synchronously gets the stats of the file or the directory, following the symbolic links.
+	//   statSync(path: string): RuntimeStats
+	fnStatSync *v8.FunctionTemplate
+	// Synchronously gets the stats of the path, without following the symbolic link at its end.
+	//   lstatSync(path: string): RuntimeStats
+	fnLstatSync *v8.FunctionTemplate
+
 	// See https://nodejs.org/api/fs.html#fs_fs_realpathsync_path_options
 	//   realpathSync(path: string): string
 	fnRealpathSync *v8.FunctionTemplate
@@ -93,6 +125,14 @@ type V8FileSystemHost struct {
 	// Synchronously uses pattern matching to find files or directories.
 	//   globSync(patterns: ReadonlyArray<string>): string[]
 	fnGlobSync *v8.FunctionTemplate
+
+	// Watches the changes of a file, the file system must implement filesystem.Watcher.
+	// The kind is 0 when the file is created, 1 when it is changed and 2 when it is deleted.
+	//   watchFile(path: string, callback: (fileName: string, kind: number) => void): FileWatcher
+	fnWatchFile *v8.FunctionTemplate
+	// Watches the changes in a directory, the file system must implement filesystem.Watcher.
+	//   watchDirectory(path: string, callback: (fileName: string) => void, recursive?: boolean): FileWatcher
+	fnWatchDirectory *v8.FunctionTemplate
 }
 
 func extractArg(info *v8.FunctionCallbackInfo, index int) (*v8.Value, error) {
@@ -133,6 +173,17 @@ func extractOptStringArg(info *v8.FunctionCallbackInfo, index int) (string, bool
 	}
 }
 
+func extractBytesArg(utils *V8Utils, info *v8.FunctionCallbackInfo, index int) ([]byte, error) {
+	value, err := extractArg(info, index)
+	if err != nil {
+		return nil, err
+	}
+	if !value.IsArrayBuffer() && !value.IsArrayBufferView() {
+		return nil, fmt.Errorf("the arg %d is not an ArrayBuffer or an ArrayBufferView", index)
+	}
+	return utils.Bytes(value)
+}
+
 func extractStringsArg(info *v8.FunctionCallbackInfo, index int) ([]string, error) {
 	value, err := extractArg(info, index)
 	if err != nil {
@@ -166,6 +217,32 @@ func mustWrapError(utils *V8Utils, err error) *v8.Value {
 	return ex
 }
 
+const (
+	notFoundFile      = "File"
+	notFoundDirectory = "Directory"
+)
+
+// toNotFoundError converts the not found errors of the file system, such as filesystem.NewFileOrDirNotExists,
+// to the errors.FileNotFoundError or errors.DirectoryNotFoundError of ts-morph, which only checks the "ENOENT" code
+// and reads the path property. The other errors are returned as is.
+func toNotFoundError(err error, kind string, path string) error {
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	return &JSException{
+		Name:    kind + "NotFoundError",
+		Message: kind + " not found: " + path,
+		Code:    "ENOENT",
+		Path:    path,
+		Cause:   err,
+	}
+}
+
+// isNotFound reports whether the error only says the path does not exist, the exists methods return false for it.
+func isNotFound(err error) bool {
+	return errors.Is(err, fs.ErrNotExist)
+}
+
 func mustNewValue(iso *v8.Isolate, v any) *v8.Value {
 	res, err := v8.NewValue(iso, v)
 	if err != nil {
@@ -191,6 +268,152 @@ func toRuntimeDirEntry(info fs.FileInfo) map[string]any {
 	}
 }
 
+// the type bits of the unix file modes, which node reports in the mode of the stats
+const (
+	modeTypeDir     = 0o040000
+	modeTypeFile    = 0o100000
+	modeTypeSymlink = 0o120000
+)
+
+func unixMode(mode fs.FileMode) uint32 {
+	m := uint32(mode.Perm())
+	switch {
+	case mode.IsDir():
+		m |= modeTypeDir
+	case mode&fs.ModeSymlink != 0:
+		m |= modeTypeSymlink
+	case mode.IsRegular():
+		m |= modeTypeFile
+	}
+	return m
+}
+
+// toRuntimeStats converts the info to the stats, like the fs.Stats of node with the methods replaced by properties
+//
+//	interface RuntimeStats {
+//	  size: number;
+//	  mtime: Date;
+//	  mtimeMs: number;
+//	  mode: number;
+//	  isFile: boolean;
+//	  isDirectory: boolean;
+//	  isSymlink: boolean;
+//	}
+func toRuntimeStats(info fs.FileInfo) map[string]any {
+	return map[string]any{
+		"size":        float64(info.Size()),
+		"mtime":       info.ModTime(),
+		"mtimeMs":     float64(info.ModTime().UnixMicro()) / 1e3,
+		"mode":        unixMode(info.Mode()),
+		"isFile":      info.Mode().IsRegular(),
+		"isDirectory": info.IsDir(),
+		"isSymlink":   info.Mode()&fs.ModeSymlink != 0,
+	}
+}
+
+// the kinds of the file watcher events of typescript
+const (
+	fileWatcherCreated int32 = iota
+	fileWatcherChanged
+	fileWatcherDeleted
+)
+
+func fileWatcherEventKind(op filesystem.EventOp) int32 {
+	switch {
+	case op&filesystem.Create != 0:
+		return fileWatcherCreated
+	case op&filesystem.Write != 0:
+		return fileWatcherChanged
+	default:
+		return fileWatcherDeleted
+	}
+}
+
+func extractFunctionArg(info *v8.FunctionCallbackInfo, index int) (*v8.Function, error) {
+	arg, err := extractArg(info, index)
+	if err != nil {
+		return nil, err
+	}
+	if !arg.IsFunction() {
+		return nil, fmt.Errorf("the arg %d is not a function", index)
+	}
+	return arg.AsFunction()
+}
+
+// newFileWatcher watches the path and returns the js FileWatcher closing it,
+// the events are delivered on the loop until the watcher or the host is closed.
+func (fsh *V8FileSystemHost) newFileWatcher(fsys filesystem.FileSystem, path string, recursive bool, deliver func(e filesystem.Event)) (*v8.Value, error) {
+	watcher, ok := fsys.(filesystem.Watcher)
+	if !ok {
+		return nil, fmt.Errorf("unable to watch \"%s\", %w", path, errors.ErrUnsupported)
+	}
+	utils := fsh.utils
+	ctx := utils.ctx
+	iso := ctx.Isolate()
+	fsh.watchMu.Lock()
+	id := fsh.nextWatcher
+	fsh.nextWatcher++
+	fsh.watchMu.Unlock()
+	stop, err := watcher.Watch(path, recursive, func(e filesystem.Event) {
+		utils.loop.Enqueue(func() {
+			if fsh.watching(id) {
+				deliver(e)
+			}
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	fsh.watchMu.Lock()
+	if fsh.watchers == nil {
+		fsh.watchers = make(map[int]func())
+	}
+	fsh.watchers[id] = stop
+	fsh.watchMu.Unlock()
+	fnClose := v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		fsh.unwatch(id)
+		return v8.Undefined(iso)
+	})
+	obj, err := v8.NewObjectTemplate(iso).NewInstance(ctx)
+	if err == nil {
+		err = obj.Set("close", fnClose.GetFunction(ctx))
+	}
+	if err != nil {
+		fsh.unwatch(id)
+		return nil, fmt.Errorf("unable to create the watcher of \"%s\", %w", path, err)
+	}
+	return obj.Value, nil
+}
+
+func (fsh *V8FileSystemHost) watching(id int) bool {
+	fsh.watchMu.Lock()
+	defer fsh.watchMu.Unlock()
+	_, ok := fsh.watchers[id]
+	return ok
+}
+
+// unwatch stops the watcher, closing it again does nothing
+func (fsh *V8FileSystemHost) unwatch(id int) {
+	fsh.watchMu.Lock()
+	stop, ok := fsh.watchers[id]
+	delete(fsh.watchers, id)
+	fsh.watchMu.Unlock()
+	if ok {
+		stop()
+	}
+}
+
+// Close stops the watchers the js side has not closed, it is safe to call more than once.
+func (fsh *V8FileSystemHost) Close() {
+	fsh.watchMu.Lock()
+	watchers := fsh.watchers
+	fsh.watchers = nil
+	fsh.watchMu.Unlock()
+	for _, stop := range watchers {
+		stop()
+	}
+}
+
 func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost {
 	ctx := utils.ctx
 	fsh := &V8FileSystemHost{
@@ -198,6 +421,8 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		utils: utils,
 	}
 	iso := ctx.Isolate()
+	// the async methods run the file system calls on worker goroutines and settle their promises on the loop
+	loop := utils.loop
 	fsh.fnIsCaseSensitive = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
 		res, err := v8.NewValue(iso, fs.IsCaseSensitive())
 		if err != nil {
@@ -218,14 +443,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
-			err := fs.Copy(srcPath, destPath)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(v8.Undefined(iso))
+		loop.Go(func() func() {
+			err := toNotFoundError(fs.Copy(srcPath, destPath), notFoundFile, srcPath)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnCopySync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -237,7 +464,7 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
-		err = fs.Copy(srcPath, destPath)
+		err = toNotFoundError(fs.Copy(srcPath, destPath), notFoundFile, srcPath)
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		} else {
@@ -251,14 +478,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
-			err := fs.Delete(path)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(v8.Undefined(iso))
+		loop.Go(func() func() {
+			err := toNotFoundError(fs.Delete(path), notFoundFile, path)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnDeleteSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -266,7 +495,7 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
-		err = fs.Delete(path)
+		err = toNotFoundError(fs.Delete(path), notFoundFile, path)
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		} else {
@@ -280,14 +509,19 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
+		loop.Go(func() func() {
 			res, err := fs.DirectoryExists(dirPath)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(mustNewValue(iso, res))
+			if isNotFound(err) {
+				res, err = false, nil
+			}
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(mustNewValue(iso, res))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnDirectoryExistsSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -296,6 +530,9 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
 		res, err := fs.DirectoryExists(dirPath)
+		if isNotFound(err) {
+			res, err = false, nil
+		}
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
@@ -308,14 +545,19 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
+		loop.Go(func() func() {
 			res, err := fs.FileExists(filePath)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(mustNewValue(iso, res))
+			if isNotFound(err) {
+				res, err = false, nil
 			}
-		}()
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(mustNewValue(iso, res))
+				}
+			}
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnFileExistsSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -324,6 +566,9 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
 		res, err := fs.FileExists(filePath)
+		if isNotFound(err) {
+			res, err = false, nil
+		}
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
@@ -344,14 +589,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
+		loop.Go(func() func() {
 			res, err := fs.Glob(patterns)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(mustMakeValue(ctx, res))
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(mustMakeValue(ctx, res))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnGlobSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -372,14 +619,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
+		loop.Go(func() func() {
 			err := fs.Mkdir(dirPath)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(v8.Undefined(iso))
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnMkdirSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -405,14 +654,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func() {
-			err := fs.Move(srcPath, destPath)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(v8.Undefined(iso))
+		loop.Go(func() func() {
+			err := toNotFoundError(fs.Move(srcPath, destPath), notFoundFile, srcPath)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnMoveSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -424,7 +675,7 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
-		err = fs.Move(srcPath, destPath)
+		err = toNotFoundError(fs.Move(srcPath, destPath), notFoundFile, srcPath)
 		if err != nil {
 			return iso.ThrowException(mustWrapError(utils, err))
 		} else {
@@ -438,6 +689,7 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		}
 		infoes, err := fs.ReadDir(dirPath)
 		if err != nil {
+			err = toNotFoundError(err, notFoundDirectory, dirPath)
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
 		result := make([]map[string]any, 0, len(infoes))
@@ -465,14 +717,17 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		if !ok {
 			encoding = "utf-8"
 		}
-		go func ()  {
+		loop.Go(func() func() {
 			content, err := fs.ReadFile(filePath, encoding)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(mustNewValue(iso, content))
+			err = toNotFoundError(err, notFoundFile, filePath)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(mustNewValue(iso, content))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnReadFileSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -489,10 +744,102 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		}
 		content, err := fs.ReadFile(filePath, encoding)
 		if err != nil {
+			err = toNotFoundError(err, notFoundFile, filePath)
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
 		return mustNewValue(iso, content)
 	})
+	fsh.fnReadFileBytes = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		resolver := mustMakeResolver(ctx)
+		filePath, err := extractStringArg(info, 0)
+		if err != nil {
+			resolver.Reject(mustWrapError(utils, err))
+			return resolver.GetPromise().Value
+		}
+		loop.Go(func() func() {
+			data, err := fs.ReadFileBytes(filePath)
+			err = toNotFoundError(err, notFoundFile, filePath)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+					return
+				}
+				value, err := utils.NewBytes(data)
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(value)
+				}
+			}
+		})
+		return resolver.GetPromise().Value
+	})
+	fsh.fnReadFileBytesSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		filePath, err := extractStringArg(info, 0)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		data, err := fs.ReadFileBytes(filePath)
+		if err != nil {
+			err = toNotFoundError(err, notFoundFile, filePath)
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		value, err := utils.NewBytes(data)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		return value
+	})
+	fsh.fnStat = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		resolver := mustMakeResolver(ctx)
+		path, err := extractStringArg(info, 0)
+		if err != nil {
+			resolver.Reject(mustWrapError(utils, err))
+			return resolver.GetPromise().Value
+		}
+		loop.Go(func() func() {
+			stat, err := fs.Stat(path)
+			err = toNotFoundError(err, notFoundFile, path)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+					return
+				}
+				value, err := MakeValue(ctx, toRuntimeStats(stat))
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(value)
+				}
+			}
+		})
+		return resolver.GetPromise().Value
+	})
+	statSync := func(lstat bool) *v8.FunctionTemplate {
+		return v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+			path, err := extractStringArg(info, 0)
+			if err != nil {
+				return iso.ThrowException(mustWrapError(utils, err))
+			}
+			var stat filesystem.FileInfo
+			if lstat {
+				stat, err = filesystem.Lstat(fs, path)
+			} else {
+				stat, err = fs.Stat(path)
+			}
+			if err != nil {
+				err = toNotFoundError(err, notFoundFile, path)
+				return iso.ThrowException(mustWrapError(utils, err))
+			}
+			value, err := MakeValue(info.Context(), toRuntimeStats(stat))
+			if err != nil {
+				return iso.ThrowException(mustWrapError(utils, err))
+			}
+			return value
+		})
+	}
+	fsh.fnStatSync = statSync(false)
+	fsh.fnLstatSync = statSync(true)
 	fsh.fnRealpathSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
 		path, err := extractStringArg(info, 0)
 		if err != nil {
@@ -500,6 +847,7 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		}
 		res, err := fs.Realpath(path)
 		if err != nil {
+			err = toNotFoundError(err, notFoundFile, path)
 			return iso.ThrowException(mustWrapError(utils, err))
 		}
 		return mustNewValue(iso, res)
@@ -516,14 +864,16 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 			resolver.Reject(mustWrapError(utils, err))
 			return resolver.GetPromise().Value
 		}
-		go func ()  {
+		loop.Go(func() func() {
 			err := fs.WriteFile(filePath, fileText)
-			if err != nil {
-				resolver.Reject(mustWrapError(utils, err))
-			} else {
-				resolver.Resolve(v8.Undefined(iso))
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
 			}
-		}()
+		})
 		return resolver.GetPromise().Value
 	})
 	fsh.fnWriteFileSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
@@ -541,6 +891,84 @@ func NewV8FileSystem(fs filesystem.FileSystem, utils *V8Utils) *V8FileSystemHost
 		}
 		return v8.Undefined(iso)
 	})
+	fsh.fnWriteFileBytes = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		resolver := mustMakeResolver(ctx)
+		filePath, err := extractStringArg(info, 0)
+		if err != nil {
+			resolver.Reject(mustWrapError(utils, err))
+			return resolver.GetPromise().Value
+		}
+		data, err := extractBytesArg(utils, info, 1)
+		if err != nil {
+			resolver.Reject(mustWrapError(utils, err))
+			return resolver.GetPromise().Value
+		}
+		loop.Go(func() func() {
+			err := fs.WriteFileBytes(filePath, data)
+			return func() {
+				if err != nil {
+					resolver.Reject(mustWrapError(utils, err))
+				} else {
+					resolver.Resolve(v8.Undefined(iso))
+				}
+			}
+		})
+		return resolver.GetPromise().Value
+	})
+	fsh.fnWriteFileBytesSync = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		filePath, err := extractStringArg(info, 0)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		data, err := extractBytesArg(utils, info, 1)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		err = fs.WriteFileBytes(filePath, data)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		return v8.Undefined(iso)
+	})
+	fsh.fnWatchFile = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		path, err := extractStringArg(info, 0)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		callback, err := extractFunctionArg(info, 1)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		watcher, err := fsh.newFileWatcher(fs, path, false, func(e filesystem.Event) {
+			// the exceptions of the callback are not reported, like the ones of the node watchers
+			_, _ = callback.Call(v8.Undefined(iso), mustNewValue(iso, path), mustNewValue(iso, fileWatcherEventKind(e.Op)))
+		})
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		return watcher
+	})
+	fsh.fnWatchDirectory = v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		path, err := extractStringArg(info, 0)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		callback, err := extractFunctionArg(info, 1)
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		recursive := false
+		if arg := extractOptArg(info, 2); arg != nil {
+			recursive = arg.Boolean()
+		}
+		watcher, err := fsh.newFileWatcher(fs, path, recursive, func(e filesystem.Event) {
+			_, _ = callback.Call(v8.Undefined(iso), mustNewValue(iso, e.Path))
+		})
+		if err != nil {
+			return iso.ThrowException(mustWrapError(utils, err))
+		}
+		return watcher
+	})
 	return fsh
 }
 
@@ -611,6 +1039,10 @@ func (fs *V8FileSystemHost) CreateObjectTemplate() (*v8.ObjectTemplate, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = setMethod(t, "lstatSync", fs.fnLstatSync)
+	if err != nil {
+		return nil, err
+	}
 	err = setMethod(t, "move", fs.fnMove)
 	if err != nil {
 		return nil, err
@@ -631,10 +1063,34 @@ func (fs *V8FileSystemHost) CreateObjectTemplate() (*v8.ObjectTemplate, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = setMethod(t, "readFileBytes", fs.fnReadFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = setMethod(t, "readFileBytesSync", fs.fnReadFileBytesSync)
+	if err != nil {
+		return nil, err
+	}
 	err = setMethod(t, "realpathSync", fs.fnRealpathSync)
 	if err != nil {
 		return nil, err
 	}
+	err = setMethod(t, "stat", fs.fnStat)
+	if err != nil {
+		return nil, err
+	}
+	err = setMethod(t, "statSync", fs.fnStatSync)
+	if err != nil {
+		return nil, err
+	}
+	err = setMethod(t, "watchDirectory", fs.fnWatchDirectory)
+	if err != nil {
+		return nil, err
+	}
+	err = setMethod(t, "watchFile", fs.fnWatchFile)
+	if err != nil {
+		return nil, err
+	}
 	err = setMethod(t, "writeFile", fs.fnWriteFile)
 	if err != nil {
 		return nil, err
@@ -643,6 +1099,14 @@ func (fs *V8FileSystemHost) CreateObjectTemplate() (*v8.ObjectTemplate, error) {
 	if err != nil {
 		return nil, err
 	}
+	err = setMethod(t, "writeFileBytes", fs.fnWriteFileBytes)
+	if err != nil {
+		return nil, err
+	}
+	err = setMethod(t, "writeFileBytesSync", fs.fnWriteFileBytesSync)
+	if err != nil {
+		return nil, err
+	}
 	return t, nil
 }
 
@@ -0,0 +1,69 @@
+package filesystem
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io/fs"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// DecodeText decodes the file content by the node encoding, one of "utf8", "latin1", "utf16le" and "base64",
+// and their aliases "utf-8", "binary", "utf-16le", "ucs2" and "ucs-2". The empty encoding is utf8.
+// The implementations of FileSystem.ReadFile can read the bytes and decode them with it.
+func DecodeText(data []byte, encoding string) (string, error) {
+	switch strings.ToLower(encoding) {
+	case "", "utf8", "utf-8":
+		return string(data), nil
+	case "latin1", "binary":
+		var sb strings.Builder
+		sb.Grow(len(data))
+		for _, b := range data {
+			sb.WriteRune(rune(b))
+		}
+		return sb.String(), nil
+	case "utf16le", "utf-16le", "ucs2", "ucs-2":
+		// the trailing odd byte is dropped like node does
+		units := make([]uint16, len(data)/2)
+		for i := range units {
+			units[i] = uint16(data[2*i]) | uint16(data[2*i+1])<<8
+		}
+		return string(utf16.Decode(units)), nil
+	case "base64":
+		return base64.StdEncoding.EncodeToString(data), nil
+	default:
+		return "", newUnsupportedEncoding(encoding)
+	}
+}
+
+// EncodeText encodes the text by the node encoding, it is the reverse of DecodeText.
+// The chars out of latin1 are truncated to their low bytes like node does.
+func EncodeText(text string, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "", "utf8", "utf-8":
+		return []byte(text), nil
+	case "latin1", "binary":
+		data := make([]byte, 0, utf8.RuneCountInString(text))
+		for _, r := range text {
+			data = append(data, byte(r))
+		}
+		return data, nil
+	case "utf16le", "utf-16le", "ucs2", "ucs-2":
+		units := utf16.Encode([]rune(text))
+		data := make([]byte, 0, 2*len(units))
+		for _, u := range units {
+			data = append(data, byte(u), byte(u>>8))
+		}
+		return data, nil
+	case "base64":
+		// the padding is optional like node does
+		data, err := base64.RawStdEncoding.DecodeString(strings.TrimRight(text, "="))
+		if err != nil {
+			return nil, fmt.Errorf("%w, invalid base64 text, %w", fs.ErrInvalid, err)
+		}
+		return data, nil
+	default:
+		return nil, newUnsupportedEncoding(encoding)
+	}
+}
@@ -0,0 +1,37 @@
+package filesystem_test
+
+import (
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestEncodeText(t *testing.T) {
+	for _, encoding := range []string{"utf8", "latin1", "utf16le", "ucs2", "base64"} {
+		for _, data := range [][]byte{{}, []byte("héllo, 世界"), {0, 0x80, 0xff}} {
+			if encoding == "utf8" && string(data) != string([]rune(string(data))) {
+				continue
+			}
+			if (encoding == "utf16le" || encoding == "ucs2") && len(data)%2 == 1 {
+				data = append(data, 0)
+			}
+			text, err := filesystem.DecodeText(data, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			encoded, err := filesystem.EncodeText(text, encoding)
+			if err != nil {
+				t.Fatal(err)
+			}
+			test.AssertEqual(t, string(data), string(encoded), encoding+": ")
+		}
+	}
+	data, err := filesystem.EncodeText("AID/YQ", "base64")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, "\x00\x80\xffa", string(data), "")
+	_, err = filesystem.EncodeText("", "ascii85")
+	test.AssertEqual(t, true, err != nil, "")
+}
@@ -0,0 +1,58 @@
+package filesystem
+
+import (
+	"fmt"
+	"io/fs"
+)
+
+// the sentinel errors of the file systems, test them with errors.Is
+var (
+	// ErrNotExist means the file or the directory does not exist, it is fs.ErrNotExist.
+	ErrNotExist = fs.ErrNotExist
+	// ErrNotDir means a directory is expected, but the path is a file, it is a fs.ErrInvalid.
+	ErrNotDir error = &sentinelError{"not a directory", fs.ErrInvalid}
+	// ErrNotFile means a file is expected, but the path is a directory, it is a fs.ErrInvalid.
+	ErrNotFile error = &sentinelError{"not a file", fs.ErrInvalid}
+	// ErrReadOnly is returned by the write methods of a read-only file system, it is a fs.ErrPermission.
+	ErrReadOnly error = &sentinelError{"the file system is read-only", fs.ErrPermission}
+	// ErrOutOfSandbox means the path escapes the root of SandboxFS, it is a fs.ErrPermission.
+	ErrOutOfSandbox error = &sentinelError{"the path is out of the sandbox", fs.ErrPermission}
+)
+
+// sentinelError is a sentinel error specializing a more generic one, so both match it
+type sentinelError struct {
+	msg    string
+	parent error
+}
+
+func (e *sentinelError) Error() string {
+	return e.msg
+}
+
+func (e *sentinelError) Unwrap() error {
+	return e.parent
+}
+
+func NewFileOrDirNotExists(path string) error {
+	return fmt.Errorf("%w, path: %s", ErrNotExist, path)
+}
+
+func NewNotDir(path string) error {
+	return fmt.Errorf("%w, the input path \"%s\" is not a dir", ErrNotDir, path)
+}
+
+func NewNotFile(path string) error {
+	return fmt.Errorf("%w, the input path \"%s\" is not a file", ErrNotFile, path)
+}
+
+func newCopyIntoItself(path string) error {
+	return fmt.Errorf("%w, unable to copy the dir \"%s\" into itself", fs.ErrInvalid, path)
+}
+
+func newOutOfSandbox(path string) error {
+	return fmt.Errorf("%w, path: %s", ErrOutOfSandbox, path)
+}
+
+func newUnsupportedEncoding(encoding string) error {
+	return fmt.Errorf("%w, unsupported encoding \"%s\"", fs.ErrInvalid, encoding)
+}
@@ -0,0 +1,167 @@
+package filesystem_test
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/filesystem/filesystemtest"
+)
+
+func newMemoryFS(caseSensitive bool) filesystemtest.Factory {
+	return func(t *testing.T, files map[string]string) filesystem.FileSystem {
+		mfs := filesystem.NewMemoryFS(caseSensitive)
+		for path, content := range files {
+			if err := mfs.Mkdir(path[:strings.LastIndex(path, "/")+1]); err != nil {
+				t.Fatal(err)
+			}
+			if err := mfs.WriteFile(path, content); err != nil {
+				t.Fatal(err)
+			}
+		}
+		return mfs
+	}
+}
+
+func TestMemoryFS(t *testing.T) {
+	t.Run("CaseSensitive", func(t *testing.T) {
+		filesystemtest.TestFileSystem(t, newMemoryFS(true))
+	})
+	t.Run("CaseInsensitive", func(t *testing.T) {
+		filesystemtest.TestFileSystem(t, newMemoryFS(false))
+	})
+}
+
+// run with -race, the writers change the nodes the readers walk
+func TestMemoryFSConcurrent(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	var wg sync.WaitGroup
+	errs := make(chan error, 64)
+	for i := 0; i < 8; i++ {
+		dir := "/dir" + strconv.Itoa(i%2)
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				name := dir + "/" + strconv.Itoa(i) + "-" + strconv.Itoa(j) + ".txt"
+				if err := mfs.Mkdir(dir); err != nil {
+					errs <- err
+					return
+				}
+				if err := mfs.WriteFile(name, strings.Repeat("a", j)); err != nil {
+					errs <- err
+					return
+				}
+				if err := mfs.Copy(name, name+".bak"); err != nil {
+					errs <- err
+					return
+				}
+				if err := mfs.Delete(name + ".bak"); err != nil {
+					errs <- err
+					return
+				}
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				if _, err := mfs.Glob([]string{dir + "/*.txt"}); err != nil {
+					errs <- err
+					return
+				}
+				if infos, err := mfs.ReadDir("/"); err == nil {
+					for _, info := range infos {
+						_ = info.Size()
+						_ = info.ModTime()
+					}
+				}
+				if info, err := mfs.Stat(dir); err == nil {
+					_ = info.Size()
+				}
+				_, _ = mfs.ReadFile(dir+"/0-0.txt", "utf-8")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Fatal(err)
+	}
+	files, err := mfs.Glob([]string{"/**/*.txt"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 160 {
+		t.Fatalf("expect 160 files, but got %d", len(files))
+	}
+}
+
+func TestIOFS(t *testing.T) {
+	filesystemtest.TestFileSystem(t, func(t *testing.T, files map[string]string) filesystem.FileSystem {
+		mapFS := fstest.MapFS{}
+		for path, content := range files {
+			mapFS[path[1:]] = &fstest.MapFile{Data: []byte(content)}
+		}
+		return filesystem.NewIOFS(mapFS)
+	})
+}
+
+func newSandboxFS(opts ...filesystem.SandboxOption) filesystemtest.Factory {
+	return func(t *testing.T, files map[string]string) filesystem.FileSystem {
+		root := t.TempDir()
+		for path, content := range files {
+			hostPath := filepath.Join(root, filepath.FromSlash(path))
+			if err := os.MkdirAll(filepath.Dir(hostPath), 0770); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(hostPath, []byte(content), 0660); err != nil {
+				t.Fatal(err)
+			}
+		}
+		sfs, err := filesystem.NewSandboxFS(root, opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sfs
+	}
+}
+
+func TestSandboxFS(t *testing.T) {
+	filesystemtest.TestFileSystem(t, newSandboxFS())
+	t.Run("ReadOnly", func(t *testing.T) {
+		filesystemtest.TestFileSystem(t, newSandboxFS(filesystem.WithReadOnly()))
+	})
+}
+
+func TestSetModTime(t *testing.T) {
+	modTime := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	for name, factory := range map[string]filesystemtest.Factory{
+		"MemoryFS":  newMemoryFS(true),
+		"SandboxFS": newSandboxFS(),
+	} {
+		t.Run(name, func(t *testing.T) {
+			fsys := factory(t, map[string]string{"/src/a.ts": "a"})
+			if err := filesystem.SetModTime(fsys, "/src/a.ts", modTime); err != nil {
+				t.Fatal(err)
+			}
+			info, err := fsys.Stat("/src/a.ts")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !info.ModTime().Equal(modTime) {
+				t.Fatalf("expect the modification time %v, but got %v", modTime, info.ModTime())
+			}
+			if err := filesystem.SetModTime(fsys, "/src/missing.ts", modTime); !errors.Is(err, fs.ErrNotExist) {
+				t.Fatalf("expect fs.ErrNotExist, but got %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,443 @@
+// Package filesystemtest implements the support for testing the implementations of filesystem.FileSystem.
+package filesystemtest
+
+import (
+	"errors"
+	"io/fs"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+)
+
+// Factory creates the file system under test holding the files, which are keyed by their absolute slash paths.
+// The parent directories of the files exist too, and the current directory is "/".
+type Factory func(t *testing.T, files map[string]string) filesystem.FileSystem
+
+// the files every test starts with
+var testFiles = map[string]string{
+	"/README.md":          "readme",
+	"/src/index.ts":       "export * from './lib/a';",
+	"/src/lib/a.ts":       "export const a = 1;",
+	"/src/lib/b.ts":       "export const b = 2;",
+	"/src/lib/data.json":  "{}",
+	"/src/empty/.keep":    "",
+	"/test/index.test.ts": "",
+}
+
+// TestFileSystem checks the file system created by the factory against the spec shared by the implementations:
+//   - the paths are resolved against the current directory and cleaned, so "src/../src/a.ts" is "/src/a.ts";
+//   - a trailing slash means a directory, so ReadFile("/src/a.ts/") fails with filesystem.ErrNotFile;
+//   - a case-insensitive file system, whose IsCaseSensitive returns false, folds the case of the paths;
+//   - ReadDir returns the direct children, Glob returns the matching files and "**" matches any directories;
+//   - Mkdir, Copy and Move create the missing parents, WriteFile fails with filesystem.ErrNotExist on them.
+//
+// The write methods are only checked if the file system is writable, a read-only file system must fail them
+// with an error wrapping fs.ErrPermission, such as filesystem.ErrReadOnly.
+//
+//	func TestMyFS(t *testing.T) {
+//		filesystemtest.TestFileSystem(t, func(t *testing.T, files map[string]string) filesystem.FileSystem {
+//			return newMyFS(files)
+//		})
+//	}
+func TestFileSystem(t *testing.T, factory Factory) {
+	t.Helper()
+	newFS := func(t *testing.T) filesystem.FileSystem {
+		t.Helper()
+		files := make(map[string]string, len(testFiles))
+		for path, content := range testFiles {
+			files[path] = content
+		}
+		return factory(t, files)
+	}
+	readOnly := isReadOnly(newFS(t))
+	for _, c := range []struct {
+		name  string
+		write bool
+		test  func(t *testing.T, fsys filesystem.FileSystem)
+	}{
+		{"ReadFile", false, testReadFile},
+		{"Encoding", false, testEncoding},
+		{"WriteFileBytes", true, testWriteFileBytes},
+		{"Exists", false, testExists},
+		{"ReadDir", false, testReadDir},
+		{"Realpath", false, testRealpath},
+		{"Stat", false, testStat},
+		{"StatWrite", true, testStatWrite},
+		{"Glob", false, testGlob},
+		{"WriteFile", true, testWriteFile},
+		{"Mkdir", true, testMkdir},
+		{"Delete", true, testDelete},
+		{"Move", true, testMove},
+		{"Copy", true, testCopy},
+		{"TrailingSlash", false, testTrailingSlash},
+		{"TrailingSlashWrite", true, testTrailingSlashWrite},
+		{"CaseFolding", false, testCaseFolding},
+		{"CaseFoldingWrite", true, testCaseFoldingWrite},
+		{"RelativePath", false, testRelativePath},
+		{"RelativePathWrite", true, testRelativePathWrite},
+		{"MissingParent", true, testMissingParent},
+	} {
+		t.Run(c.name, func(t *testing.T) {
+			fsys := newFS(t)
+			if c.write && readOnly {
+				testReadOnly(t, fsys)
+				return
+			}
+			c.test(t, fsys)
+		})
+	}
+}
+
+func isReadOnly(fsys filesystem.FileSystem) bool {
+	return errors.Is(fsys.WriteFile("/src/lib/a.ts", ""), fs.ErrPermission)
+}
+
+func check(t *testing.T, op string, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatalf("%s: unexpected error, %v", op, err)
+	}
+}
+
+func checkErr(t *testing.T, op string, err error, target error) {
+	t.Helper()
+	if !errors.Is(err, target) {
+		t.Errorf("%s: expect an error matching %q, but got %v", op, target, err)
+	}
+}
+
+func checkEqual[T comparable](t *testing.T, op string, expected T, real T) {
+	t.Helper()
+	if expected != real {
+		t.Errorf("%s: expect %v, but got %v", op, expected, real)
+	}
+}
+
+func checkFile(t *testing.T, fsys filesystem.FileSystem, path string, content string) {
+	t.Helper()
+	got, err := fsys.ReadFile(path, "utf-8")
+	check(t, "ReadFile("+path+")", err)
+	checkEqual(t, "ReadFile("+path+")", content, got)
+}
+
+func checkExists(t *testing.T, fsys filesystem.FileSystem, path string, file bool, dir bool) {
+	t.Helper()
+	exists, err := fsys.FileExists(path)
+	check(t, "FileExists("+path+")", err)
+	checkEqual(t, "FileExists("+path+")", file, exists)
+	exists, err = fsys.DirectoryExists(path)
+	check(t, "DirectoryExists("+path+")", err)
+	checkEqual(t, "DirectoryExists("+path+")", dir, exists)
+}
+
+func sorted(paths []string) string {
+	paths = append([]string(nil), paths...)
+	sort.Strings(paths)
+	return strings.Join(paths, ",")
+}
+
+func testReadFile(t *testing.T, fsys filesystem.FileSystem) {
+	checkFile(t, fsys, "/src/lib/a.ts", testFiles["/src/lib/a.ts"])
+	checkFile(t, fsys, "/README.md", testFiles["/README.md"])
+	_, err := fsys.ReadFile("/src/lib/missing.ts", "utf-8")
+	checkErr(t, "ReadFile(/src/lib/missing.ts)", err, filesystem.ErrNotExist)
+	_, err = fsys.ReadFile("/missing/a.ts", "utf-8")
+	checkErr(t, "ReadFile(/missing/a.ts)", err, filesystem.ErrNotExist)
+	_, err = fsys.ReadFile("/src/lib", "utf-8")
+	checkErr(t, "ReadFile(/src/lib)", err, filesystem.ErrNotFile)
+}
+
+func testExists(t *testing.T, fsys filesystem.FileSystem) {
+	checkExists(t, fsys, "/src/lib/a.ts", true, false)
+	checkExists(t, fsys, "/src/lib", false, true)
+	checkExists(t, fsys, "/", false, true)
+	checkExists(t, fsys, "/src/lib/missing.ts", false, false)
+	checkExists(t, fsys, "/missing/a.ts", false, false)
+}
+
+func testReadDir(t *testing.T, fsys filesystem.FileSystem) {
+	infoes, err := fsys.ReadDir("/src")
+	check(t, "ReadDir(/src)", err)
+	names := make([]string, 0, len(infoes))
+	for _, info := range infoes {
+		name := info.Name()
+		if info.IsDir() {
+			name += "/"
+		}
+		names = append(names, name)
+	}
+	checkEqual(t, "ReadDir(/src)", "empty/,index.ts,lib/", sorted(names))
+	_, err = fsys.ReadDir("/missing")
+	checkErr(t, "ReadDir(/missing)", err, filesystem.ErrNotExist)
+	_, err = fsys.ReadDir("/src/index.ts")
+	checkErr(t, "ReadDir(/src/index.ts)", err, filesystem.ErrNotDir)
+}
+
+func testRealpath(t *testing.T, fsys filesystem.FileSystem) {
+	cwd, err := fsys.GetCurrentDirectory()
+	check(t, "GetCurrentDirectory()", err)
+	checkEqual(t, "GetCurrentDirectory()", "/", cwd)
+	path, err := fsys.Realpath("/src/lib/a.ts")
+	check(t, "Realpath(/src/lib/a.ts)", err)
+	checkEqual(t, "Realpath(/src/lib/a.ts)", "/src/lib/a.ts", path)
+}
+
+func testGlob(t *testing.T, fsys filesystem.FileSystem) {
+	for _, c := range []struct {
+		patterns []string
+		expected string
+	}{
+		{[]string{"/src/lib/*.ts"}, "/src/lib/a.ts,/src/lib/b.ts"},
+		{[]string{"/src/*.ts", "/test/*.ts"}, "/src/index.ts,/test/index.test.ts"},
+		{[]string{"/src/lib/d*.json", "/src/lib/*.json"}, "/src/lib/data.json"},
+		{[]string{"/src/**/*.ts"}, "/src/index.ts,/src/lib/a.ts,/src/lib/b.ts"},
+		{[]string{"/**/index*.ts"}, "/src/index.ts,/test/index.test.ts"},
+		{[]string{"/src/lib/**"}, "/src/lib/a.ts,/src/lib/b.ts,/src/lib/data.json"},
+		{[]string{"/src/*"}, "/src/index.ts"},
+		{[]string{"/missing/*.ts"}, ""},
+	} {
+		paths, err := fsys.Glob(c.patterns)
+		op := "Glob(" + strings.Join(c.patterns, ",") + ")"
+		check(t, op, err)
+		checkEqual(t, op, c.expected, sorted(paths))
+	}
+}
+
+func testWriteFile(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "WriteFile(/src/lib/c.ts)", fsys.WriteFile("/src/lib/c.ts", "export const c = 3;"))
+	checkFile(t, fsys, "/src/lib/c.ts", "export const c = 3;")
+	check(t, "WriteFile(/src/lib/a.ts)", fsys.WriteFile("/src/lib/a.ts", ""))
+	checkFile(t, fsys, "/src/lib/a.ts", "")
+	checkErr(t, "WriteFile(/src/lib)", fsys.WriteFile("/src/lib", ""), filesystem.ErrNotFile)
+}
+
+func testMkdir(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "Mkdir(/out/lib)", fsys.Mkdir("/out/lib"))
+	checkExists(t, fsys, "/out", false, true)
+	checkExists(t, fsys, "/out/lib", false, true)
+	check(t, "Mkdir(/src/lib)", fsys.Mkdir("/src/lib"))
+	checkFile(t, fsys, "/src/lib/a.ts", testFiles["/src/lib/a.ts"])
+}
+
+func testDelete(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "Delete(/src/lib/a.ts)", fsys.Delete("/src/lib/a.ts"))
+	checkExists(t, fsys, "/src/lib/a.ts", false, false)
+	checkExists(t, fsys, "/src/lib/b.ts", true, false)
+	check(t, "Delete(/src/lib)", fsys.Delete("/src/lib"))
+	checkExists(t, fsys, "/src/lib", false, false)
+	checkExists(t, fsys, "/src/lib/b.ts", false, false)
+	checkErr(t, "Delete(/missing)", fsys.Delete("/missing"), filesystem.ErrNotExist)
+}
+
+func testMove(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "Move(/src/lib/a.ts, /src/c.ts)", fsys.Move("/src/lib/a.ts", "/src/c.ts"))
+	checkExists(t, fsys, "/src/lib/a.ts", false, false)
+	checkFile(t, fsys, "/src/c.ts", testFiles["/src/lib/a.ts"])
+	check(t, "Move(/src/lib, /lib)", fsys.Move("/src/lib", "/lib"))
+	checkExists(t, fsys, "/src/lib", false, false)
+	checkFile(t, fsys, "/lib/b.ts", testFiles["/src/lib/b.ts"])
+	checkErr(t, "Move(/missing, /lib)", fsys.Move("/missing", "/lib"), filesystem.ErrNotExist)
+}
+
+func testCopy(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "Copy(/src/lib/a.ts, /src/c.ts)", fsys.Copy("/src/lib/a.ts", "/src/c.ts"))
+	checkFile(t, fsys, "/src/lib/a.ts", testFiles["/src/lib/a.ts"])
+	checkFile(t, fsys, "/src/c.ts", testFiles["/src/lib/a.ts"])
+	check(t, "Copy(/src/lib, /lib)", fsys.Copy("/src/lib", "/lib"))
+	checkFile(t, fsys, "/src/lib/b.ts", testFiles["/src/lib/b.ts"])
+	checkFile(t, fsys, "/lib/b.ts", testFiles["/src/lib/b.ts"])
+	check(t, "WriteFile(/lib/b.ts)", fsys.WriteFile("/lib/b.ts", ""))
+	checkFile(t, fsys, "/src/lib/b.ts", testFiles["/src/lib/b.ts"])
+	checkErr(t, "Copy(/missing, /lib)", fsys.Copy("/missing", "/lib"), filesystem.ErrNotExist)
+}
+
+func testReadOnly(t *testing.T, fsys filesystem.FileSystem) {
+	checkErr(t, "WriteFile(/src/c.ts)", fsys.WriteFile("/src/c.ts", ""), fs.ErrPermission)
+	checkErr(t, "WriteFileBytes(/src/c.ts)", fsys.WriteFileBytes("/src/c.ts", nil), fs.ErrPermission)
+	checkErr(t, "Mkdir(/out)", fsys.Mkdir("/out"), fs.ErrPermission)
+	checkErr(t, "Delete(/src/lib/a.ts)", fsys.Delete("/src/lib/a.ts"), fs.ErrPermission)
+	checkErr(t, "Move(/src/lib/a.ts, /src/c.ts)", fsys.Move("/src/lib/a.ts", "/src/c.ts"), fs.ErrPermission)
+	checkErr(t, "Copy(/src/lib/a.ts, /src/c.ts)", fsys.Copy("/src/lib/a.ts", "/src/c.ts"), fs.ErrPermission)
+	checkFile(t, fsys, "/src/lib/a.ts", testFiles["/src/lib/a.ts"])
+}
+
+func testTrailingSlash(t *testing.T, fsys filesystem.FileSystem) {
+	checkExists(t, fsys, "/src/lib/", false, true)
+	checkExists(t, fsys, "/src/lib/a.ts/", false, false)
+	_, err := fsys.ReadFile("/src/lib/a.ts/", "utf-8")
+	checkErr(t, "ReadFile(/src/lib/a.ts/)", err, filesystem.ErrNotFile)
+	infoes, err := fsys.ReadDir("/src/lib/")
+	check(t, "ReadDir(/src/lib/)", err)
+	checkEqual(t, "len(ReadDir(/src/lib/))", 3, len(infoes))
+	_, err = fsys.ReadDir("/src/index.ts/")
+	checkErr(t, "ReadDir(/src/index.ts/)", err, filesystem.ErrNotDir)
+	paths, err := fsys.Glob([]string{"/src/lib/*.ts/"})
+	check(t, "Glob(/src/lib/*.ts/)", err)
+	checkEqual(t, "Glob(/src/lib/*.ts/)", "", sorted(paths))
+}
+
+func testTrailingSlashWrite(t *testing.T, fsys filesystem.FileSystem) {
+	checkErr(t, "WriteFile(/src/lib/c.ts/)", fsys.WriteFile("/src/lib/c.ts/", ""), filesystem.ErrNotFile)
+	check(t, "Mkdir(/out/)", fsys.Mkdir("/out/"))
+	checkExists(t, fsys, "/out", false, true)
+	check(t, "Copy(/src/lib/, /out/lib/)", fsys.Copy("/src/lib/", "/out/lib/"))
+	checkFile(t, fsys, "/out/lib/a.ts", testFiles["/src/lib/a.ts"])
+	check(t, "Delete(/out/)", fsys.Delete("/out/"))
+	checkExists(t, fsys, "/out", false, false)
+	checkErr(t, "Delete(/src/index.ts/)", fsys.Delete("/src/index.ts/"), filesystem.ErrNotDir)
+	checkExists(t, fsys, "/src/index.ts", true, false)
+}
+
+func testCaseFolding(t *testing.T, fsys filesystem.FileSystem) {
+	if fsys.IsCaseSensitive() {
+		checkExists(t, fsys, "/SRC/Lib/A.ts", false, false)
+		_, err := fsys.ReadFile("/SRC/Lib/A.ts", "utf-8")
+		checkErr(t, "ReadFile(/SRC/Lib/A.ts)", err, filesystem.ErrNotExist)
+		paths, err := fsys.Glob([]string{"/SRC/lib/*.ts"})
+		check(t, "Glob(/SRC/lib/*.ts)", err)
+		checkEqual(t, "Glob(/SRC/lib/*.ts)", "", sorted(paths))
+		return
+	}
+	checkExists(t, fsys, "/SRC/Lib/A.ts", true, false)
+	checkExists(t, fsys, "/SRC/Lib", false, true)
+	checkFile(t, fsys, "/SRC/Lib/A.ts", testFiles["/src/lib/a.ts"])
+	infoes, err := fsys.ReadDir("/SRC/LIB")
+	check(t, "ReadDir(/SRC/LIB)", err)
+	checkEqual(t, "len(ReadDir(/SRC/LIB))", 3, len(infoes))
+	paths, err := fsys.Glob([]string{"/SRC/lib/*.ts"})
+	check(t, "Glob(/SRC/lib/*.ts)", err)
+	checkEqual(t, "len(Glob(/SRC/lib/*.ts))", 2, len(paths))
+}
+
+func testCaseFoldingWrite(t *testing.T, fsys filesystem.FileSystem) {
+	if fsys.IsCaseSensitive() {
+		checkErr(t, "WriteFile(/SRC/Lib/A.ts)", fsys.WriteFile("/SRC/Lib/A.ts", ""), filesystem.ErrNotExist)
+		check(t, "WriteFile(/src/lib/A.ts)", fsys.WriteFile("/src/lib/A.ts", "export {};"))
+		checkFile(t, fsys, "/src/lib/a.ts", testFiles["/src/lib/a.ts"])
+		checkFile(t, fsys, "/src/lib/A.ts", "export {};")
+		return
+	}
+	check(t, "WriteFile(/SRC/Lib/A.ts)", fsys.WriteFile("/SRC/Lib/A.ts", "export {};"))
+	checkFile(t, fsys, "/src/lib/a.ts", "export {};")
+	check(t, "Delete(/SRC/LIB/A.TS)", fsys.Delete("/SRC/LIB/A.TS"))
+	checkExists(t, fsys, "/src/lib/a.ts", false, false)
+}
+
+func testRelativePath(t *testing.T, fsys filesystem.FileSystem) {
+	checkFile(t, fsys, "src/lib/a.ts", testFiles["/src/lib/a.ts"])
+	checkFile(t, fsys, "./src/../src/lib/./a.ts", testFiles["/src/lib/a.ts"])
+	checkFile(t, fsys, "/src/lib/../../README.md", testFiles["/README.md"])
+	checkExists(t, fsys, "src/lib", false, true)
+	checkExists(t, fsys, ".", false, true)
+	infoes, err := fsys.ReadDir("src/../src/lib")
+	check(t, "ReadDir(src/../src/lib)", err)
+	checkEqual(t, "len(ReadDir(src/../src/lib))", 3, len(infoes))
+	path, err := fsys.Realpath("src/./lib/../lib/a.ts")
+	check(t, "Realpath(src/./lib/../lib/a.ts)", err)
+	checkEqual(t, "Realpath(src/./lib/../lib/a.ts)", "/src/lib/a.ts", path)
+	paths, err := fsys.Glob([]string{"src/lib/*.ts"})
+	check(t, "Glob(src/lib/*.ts)", err)
+	checkEqual(t, "Glob(src/lib/*.ts)", "/src/lib/a.ts,/src/lib/b.ts", sorted(paths))
+}
+
+func testRelativePathWrite(t *testing.T, fsys filesystem.FileSystem) {
+	check(t, "WriteFile(src/lib/../c.ts)", fsys.WriteFile("src/lib/../c.ts", "c"))
+	checkFile(t, fsys, "/src/c.ts", "c")
+	check(t, "Mkdir(out/./lib)", fsys.Mkdir("out/./lib"))
+	checkExists(t, fsys, "/out/lib", false, true)
+	check(t, "Move(src/c.ts, out/lib/c.ts)", fsys.Move("src/c.ts", "out/lib/c.ts"))
+	checkFile(t, fsys, "/out/lib/c.ts", "c")
+	check(t, "Delete(out)", fsys.Delete("out"))
+	checkExists(t, fsys, "/out", false, false)
+}
+
+func testMissingParent(t *testing.T, fsys filesystem.FileSystem) {
+	checkErr(t, "WriteFile(/out/lib/a.ts)", fsys.WriteFile("/out/lib/a.ts", ""), filesystem.ErrNotExist)
+	checkExists(t, fsys, "/out", false, false)
+	if err := fsys.WriteFile("/src/index.ts/a.ts", ""); err == nil {
+		t.Errorf("WriteFile(/src/index.ts/a.ts): expect an error, but got nil")
+	}
+	checkErr(t, "Mkdir(/src/index.ts/lib)", fsys.Mkdir("/src/index.ts/lib"), filesystem.ErrNotDir)
+	checkFile(t, fsys, "/src/index.ts", testFiles["/src/index.ts"])
+	check(t, "Copy(/src/lib/a.ts, /out/lib/a.ts)", fsys.Copy("/src/lib/a.ts", "/out/lib/a.ts"))
+	checkFile(t, fsys, "/out/lib/a.ts", testFiles["/src/lib/a.ts"])
+	check(t, "Move(/src/lib, /dist/src/lib)", fsys.Move("/src/lib", "/dist/src/lib"))
+	checkFile(t, fsys, "/dist/src/lib/b.ts", testFiles["/src/lib/b.ts"])
+	checkExists(t, fsys, "/src/lib", false, false)
+}
+
+func testEncoding(t *testing.T, fsys filesystem.FileSystem) {
+	data, err := fsys.ReadFileBytes("/README.md")
+	check(t, "ReadFileBytes(/README.md)", err)
+	checkEqual(t, "ReadFileBytes(/README.md)", testFiles["/README.md"], string(data))
+	_, err = fsys.ReadFileBytes("/missing.md")
+	checkErr(t, "ReadFileBytes(/missing.md)", err, filesystem.ErrNotExist)
+	_, err = fsys.ReadFileBytes("/src")
+	checkErr(t, "ReadFileBytes(/src)", err, filesystem.ErrNotFile)
+	for _, c := range []struct {
+		encoding string
+		expected string
+	}{
+		{"utf8", "readme"},
+		{"latin1", "readme"},
+		{"base64", "cmVhZG1l"},
+		{"utf16le", "\u6572\u6461\u656d"},
+	} {
+		text, err := fsys.ReadFile("/README.md", c.encoding)
+		check(t, "ReadFile(/README.md, "+c.encoding+")", err)
+		checkEqual(t, "ReadFile(/README.md, "+c.encoding+")", c.expected, text)
+	}
+	_, err = fsys.ReadFile("/README.md", "unknown")
+	checkErr(t, "ReadFile(/README.md, unknown)", err, fs.ErrInvalid)
+}
+
+func testWriteFileBytes(t *testing.T, fsys filesystem.FileSystem) {
+	binary := []byte{0, 0x80, 0xff, 'a'}
+	check(t, "WriteFileBytes(/src/a.wasm)", fsys.WriteFileBytes("/src/a.wasm", binary))
+	data, err := fsys.ReadFileBytes("/src/a.wasm")
+	check(t, "ReadFileBytes(/src/a.wasm)", err)
+	checkEqual(t, "ReadFileBytes(/src/a.wasm)", string(binary), string(data))
+	text, err := fsys.ReadFile("/src/a.wasm", "latin1")
+	check(t, "ReadFile(/src/a.wasm, latin1)", err)
+	checkEqual(t, "ReadFile(/src/a.wasm, latin1)", "\x00\u0080\u00ffa", text)
+	text, err = fsys.ReadFile("/src/a.wasm", "base64")
+	check(t, "ReadFile(/src/a.wasm, base64)", err)
+	checkEqual(t, "ReadFile(/src/a.wasm, base64)", "AID/YQ==", text)
+	checkErr(t, "WriteFileBytes(/src)", fsys.WriteFileBytes("/src", binary), filesystem.ErrNotFile)
+	checkErr(t, "WriteFileBytes(/missing/a.wasm)", fsys.WriteFileBytes("/missing/a.wasm", binary), filesystem.ErrNotExist)
+}
+
+func testStat(t *testing.T, fsys filesystem.FileSystem) {
+	info, err := fsys.Stat("/src/lib/a.ts")
+	check(t, "Stat(/src/lib/a.ts)", err)
+	checkEqual(t, "Stat(/src/lib/a.ts).Name()", "a.ts", info.Name())
+	checkEqual(t, "Stat(/src/lib/a.ts).Size()", int64(len(testFiles["/src/lib/a.ts"])), info.Size())
+	checkEqual(t, "Stat(/src/lib/a.ts).IsDir()", false, info.IsDir())
+	checkEqual(t, "Stat(/src/lib/a.ts).Mode().IsRegular()", true, info.Mode().IsRegular())
+	info, err = fsys.Stat("src/lib/")
+	check(t, "Stat(src/lib/)", err)
+	checkEqual(t, "Stat(src/lib/).Name()", "lib", info.Name())
+	checkEqual(t, "Stat(src/lib/).IsDir()", true, info.IsDir())
+	_, err = fsys.Stat("/")
+	check(t, "Stat(/)", err)
+	_, err = fsys.Stat("/src/missing.ts")
+	checkErr(t, "Stat(/src/missing.ts)", err, filesystem.ErrNotExist)
+	_, err = fsys.Stat("/src/index.ts/")
+	checkErr(t, "Stat(/src/index.ts/)", err, filesystem.ErrNotDir)
+	_, err = filesystem.Lstat(fsys, "/src/index.ts")
+	check(t, "Lstat(/src/index.ts)", err)
+}
+
+func testStatWrite(t *testing.T, fsys filesystem.FileSystem) {
+	before, err := fsys.Stat("/src/lib/a.ts")
+	check(t, "Stat(/src/lib/a.ts)", err)
+	check(t, "WriteFile(/src/lib/a.ts)", fsys.WriteFile("/src/lib/a.ts", "export {};"))
+	after, err := fsys.Stat("/src/lib/a.ts")
+	check(t, "Stat(/src/lib/a.ts)", err)
+	checkEqual(t, "Stat(/src/lib/a.ts).Size()", int64(len("export {};")), after.Size())
+	if after.ModTime().Before(before.ModTime()) {
+		t.Errorf("Stat(/src/lib/a.ts).ModTime(): expect not before %v, but got %v", before.ModTime(), after.ModTime())
+	}
+}
@@ -0,0 +1,68 @@
+// Package filesystem defines the FileSystem the compiler works on and its built-in implementations,
+// MemoryFS keeps the files in memory, SandboxFS confines them to a directory of the host and IOFS reads an io/fs.FS.
+package filesystem
+
+import (
+	"io/fs"
+	"time"
+)
+
+// FileSystem is the file system the compiler reads the sources from and writes the outputs to,
+// the paths are slash separated. The implementations can be checked by filesystemtest.TestFileSystem.
+type FileSystem interface {
+	IsCaseSensitive() bool
+	Delete(path string) error
+	ReadDir(dirPath string) ([]fs.FileInfo, error)
+	// ReadFile reads the file decoded by the node encoding, such as "utf8", "latin1", "utf16le" or "base64",
+	// see DecodeText.
+	ReadFile(filePath string, encoding string) (string, error)
+	WriteFile(filePath string, fileText string) error
+	ReadFileBytes(filePath string) ([]byte, error)
+	WriteFileBytes(filePath string, data []byte) error
+	Mkdir(dirPath string) error
+	Move(srcPath string, destPath string) error
+	Copy(srcPath string, destPath string) error
+	FileExists(filePath string) (bool, error)
+	DirectoryExists(dirPath string) (bool, error)
+	// Stat returns the info of the file or the directory, following the symbolic links.
+	Stat(path string) (fs.FileInfo, error)
+	Realpath(path string) (string, error)
+	GetCurrentDirectory() (string, error)
+	Glob(patterns []string) ([]string, error)
+}
+
+// LstatFileSystem is implemented by the file systems having symbolic links, such as SandboxFS.
+type LstatFileSystem interface {
+	FileSystem
+	// Lstat returns the info of the path without following the symbolic link at its end.
+	Lstat(path string) (fs.FileInfo, error)
+}
+
+// Lstat calls the Lstat method of the file system if it has one, otherwise Stat.
+func Lstat(fsys FileSystem, path string) (fs.FileInfo, error) {
+	if lfs, ok := fsys.(LstatFileSystem); ok {
+		return lfs.Lstat(path)
+	}
+	return fsys.Stat(path)
+}
+
+// ModTimeFileSystem is implemented by the file systems able to change the modification times,
+// such as MemoryFS and SandboxFS.
+type ModTimeFileSystem interface {
+	FileSystem
+	// SetModTime sets the modification time of the file or the directory.
+	SetModTime(path string, modTime time.Time) error
+}
+
+// SetModTime calls the SetModTime method of the file system if it has one, otherwise it writes
+// the file again with its content, which sets its modification time to now instead.
+func SetModTime(fsys FileSystem, path string, modTime time.Time) error {
+	if mfs, ok := fsys.(ModTimeFileSystem); ok {
+		return mfs.SetModTime(path, modTime)
+	}
+	data, err := fsys.ReadFileBytes(path)
+	if err != nil {
+		return err
+	}
+	return fsys.WriteFileBytes(path, data)
+}
@@ -0,0 +1,179 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	idpath "path"
+	"sort"
+	"strings"
+)
+
+// IOFS is a read-only FileSystem over an io/fs.FS, such as embed.FS, zip.Reader or fstest.MapFS.
+// The root of the fs.FS is mounted at "/", which is also the current directory.
+type IOFS struct {
+	fsys fs.FS
+}
+
+func NewIOFS(fsys fs.FS) *IOFS {
+	return &IOFS{
+		fsys: fsys,
+	}
+}
+
+// name converts the absolute or relative slash path to the name used by the fs.FS
+func (s *IOFS) name(path string) string {
+	path = idpath.Clean("/" + path)
+	if path == "/" {
+		return "."
+	}
+	return path[1:]
+}
+
+func (s *IOFS) IsCaseSensitive() bool {
+	return true
+}
+
+func (s *IOFS) Delete(path string) error {
+	return fmt.Errorf("unable to delete \"%s\", %w", path, ErrReadOnly)
+}
+
+func (s *IOFS) ReadDir(dirPath string) ([]fs.FileInfo, error) {
+	info, err := fs.Stat(s.fsys, s.name(dirPath))
+	if err == nil && !info.IsDir() {
+		return nil, NewNotDir(dirPath)
+	}
+	entries, err := fs.ReadDir(s.fsys, s.name(dirPath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+	}
+	infoes := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+		}
+		infoes = append(infoes, info)
+	}
+	return infoes, nil
+}
+
+func (s *IOFS) ReadFileBytes(filePath string) ([]byte, error) {
+	info, err := fs.Stat(s.fsys, s.name(filePath))
+	if err == nil && (info.IsDir() || strings.HasSuffix(filePath, "/")) {
+		return nil, NewNotFile(filePath)
+	}
+	bytes, err := fs.ReadFile(s.fsys, s.name(filePath))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file \"%s\", %w", filePath, err)
+	}
+	return bytes, nil
+}
+
+func (s *IOFS) ReadFile(filePath string, encoding string) (string, error) {
+	bytes, err := s.ReadFileBytes(filePath)
+	if err != nil {
+		return "", err
+	}
+	text, err := DecodeText(bytes, encoding)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file \"%s\", %w", filePath, err)
+	}
+	return text, nil
+}
+
+func (s *IOFS) WriteFile(filePath string, fileText string) error {
+	return fmt.Errorf("unable to write file \"%s\", %w", filePath, ErrReadOnly)
+}
+
+func (s *IOFS) WriteFileBytes(filePath string, data []byte) error {
+	return fmt.Errorf("unable to write file \"%s\", %w", filePath, ErrReadOnly)
+}
+
+func (s *IOFS) Mkdir(dirPath string) error {
+	return fmt.Errorf("unable to make dir \"%s\", %w", dirPath, ErrReadOnly)
+}
+
+func (s *IOFS) Move(srcPath string, destPath string) error {
+	return fmt.Errorf("unable to move source path \"%s\" to dest path \"%s\", %w", srcPath, destPath, ErrReadOnly)
+}
+
+func (s *IOFS) Copy(srcPath string, destPath string) error {
+	return fmt.Errorf("unable to copy source path \"%s\" to dest path \"%s\", %w", srcPath, destPath, ErrReadOnly)
+}
+
+func (s *IOFS) stat(path string) (fs.FileInfo, error) {
+	info, err := fs.Stat(s.fsys, s.name(path))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return info, err
+}
+
+func (s *IOFS) FileExists(filePath string) (bool, error) {
+	info, err := s.stat(filePath)
+	if err != nil {
+		return false, fmt.Errorf("unable to check the file \"%s\", %w", filePath, err)
+	}
+	return info != nil && !info.IsDir() && !strings.HasSuffix(filePath, "/"), nil
+}
+
+func (s *IOFS) DirectoryExists(dirPath string) (bool, error) {
+	info, err := s.stat(dirPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to check the dir \"%s\", %w", dirPath, err)
+	}
+	return info != nil && info.IsDir(), nil
+}
+
+func (s *IOFS) Stat(path string) (fs.FileInfo, error) {
+	info, err := fs.Stat(s.fsys, s.name(path))
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+	}
+	if !info.IsDir() && strings.HasSuffix(path, "/") {
+		return nil, NewNotDir(path)
+	}
+	return info, nil
+}
+
+func (s *IOFS) Realpath(path string) (string, error) {
+	return idpath.Clean("/" + path), nil
+}
+
+func (s *IOFS) GetCurrentDirectory() (string, error) {
+	return "/", nil
+}
+
+// Glob returns the sorted paths of the files matching any of the patterns, "**" matches any directories.
+func (s *IOFS) Glob(patterns []string) ([]string, error) {
+	m, err := newGlobMatcher(patterns, "/", false)
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, root := range m.roots() {
+		err := fs.WalkDir(s.fsys, s.name(root), func(name string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if d == nil && errors.Is(err, fs.ErrNotExist) {
+					return nil
+				}
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if path := "/" + name; m.Match(path) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob, %w", err)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+var _ FileSystem = (*IOFS)(nil)
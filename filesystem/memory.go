@@ -1,18 +1,24 @@
 package filesystem
 
 import (
-	"fmt"
 	"io/fs"
+	idpath "path"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gobwas/glob"
 )
 
+// MemoryFS is a file system in memory, it is safe for concurrent use.
 type MemoryFS struct {
+	// mu guards the nodes, the methods lock it and the unexported helpers expect it locked
+	mu            sync.RWMutex
 	root          *MemoryDirNode
 	current       *MemoryDirNode
 	caseSensitive bool
+	watchers      watchers
 }
 
 func NewMemoryFS(caseSensitive bool) *MemoryFS {
@@ -20,8 +26,8 @@ func NewMemoryFS(caseSensitive bool) *MemoryFS {
 		modeTime: time.Now(),
 	}
 	return &MemoryFS{
-		root: root,
-		current: root,
+		root:          root,
+		current:       root,
 		caseSensitive: caseSensitive,
 	}
 }
@@ -82,9 +88,11 @@ func (d *MemoryDirNode) Clean() {
 }
 
 func (d *MemoryDirNode) fullPath(sb *strings.Builder) {
-	if d.parent != nil {
-		d.parent.fullPath(sb)
+	// the root is the leading slash of its descendants
+	if d.parent == nil {
+		return
 	}
+	d.parent.fullPath(sb)
 	sb.WriteString("/")
 	sb.WriteString(d.name)
 }
@@ -92,43 +100,45 @@ func (d *MemoryDirNode) fullPath(sb *strings.Builder) {
 func (d *MemoryDirNode) FullPath() string {
 	var sb strings.Builder
 	d.fullPath(&sb)
+	if sb.Len() == 0 {
+		return "/"
+	}
 	return sb.String()
 }
 
-func (d *MemoryDirNode) deepCopy(modTime time.Time) *MemoryDirNode {
-	var children map[string]*MemoryDirNode
+func (d *MemoryDirNode) deepCopy(parent *MemoryDirNode, modTime time.Time) *MemoryDirNode {
+	dir := &MemoryDirNode{
+		parent:   parent,
+		name:     d.name,
+		size:     d.size,
+		modeTime: modTime,
+	}
 	if d.children != nil {
-		children = make(map[string]*MemoryDirNode, len(d.children))
+		dir.children = make(map[string]*MemoryDirNode, len(d.children))
 		for key, child := range d.children {
-			children[key] = child.deepCopy(modTime)
-		}	
+			dir.children[key] = child.deepCopy(dir, modTime)
+		}
 	}
-	var files map[string]*MemoryFileNode
 	if d.files != nil {
-		files = make(map[string]*MemoryFileNode, len(d.files))
+		dir.files = make(map[string]*MemoryFileNode, len(d.files))
 		for key, file := range d.files {
-			files[key] = file.copy(modTime)
-		}	
-	}
-	return &MemoryDirNode{
-		parent:   d.parent,
-		name:     d.name,
-		children: children,
-		files:    files,
-		size:     d.size,
-		modeTime: modTime,
+			dir.files[key] = file.copy(dir, modTime)
+		}
 	}
+	return dir
 }
 
-func (d *MemoryDirNode) merge(dir *MemoryDirNode, overwrite bool) bool {
-	if d.name != dir.name {
-		return false
-	}
+// merge moves the children and the files of dir into d
+func (d *MemoryDirNode) merge(dir *MemoryDirNode, overwrite bool) {
 	for dirName, dirChild := range dir.children {
 		child, ok := d.children[dirName]
 		if ok {
 			child.merge(dirChild, overwrite)
 		} else {
+			if d.children == nil {
+				d.children = make(map[string]*MemoryDirNode)
+			}
+			dirChild.parent = d
 			d.children[dirName] = dirChild
 			d.size += dirChild.Size()
 			if dirChild.modeTime.After(d.modeTime) {
@@ -143,6 +153,10 @@ func (d *MemoryDirNode) merge(dir *MemoryDirNode, overwrite bool) bool {
 				file.overwrite(dirFile)
 			}
 		} else {
+			if d.files == nil {
+				d.files = make(map[string]*MemoryFileNode)
+			}
+			dirFile.parent = d
 			d.files[fileName] = dirFile
 			d.size += dirFile.Size()
 			if dirFile.modeTime.After(d.modeTime) {
@@ -150,7 +164,47 @@ func (d *MemoryDirNode) merge(dir *MemoryDirNode, overwrite bool) bool {
 			}
 		}
 	}
-	return true
+}
+
+// memoryFileInfo is a copy of the info of a node, the nodes themselves change under the lock
+type memoryFileInfo struct {
+	name    string
+	size    int64
+	mode    fs.FileMode
+	modTime time.Time
+}
+
+func newMemoryFileInfo(info FileInfo) *memoryFileInfo {
+	return &memoryFileInfo{
+		name:    info.Name(),
+		size:    info.Size(),
+		mode:    info.Mode(),
+		modTime: info.ModTime(),
+	}
+}
+
+func (i *memoryFileInfo) Name() string {
+	return i.name
+}
+
+func (i *memoryFileInfo) Size() int64 {
+	return i.size
+}
+
+func (i *memoryFileInfo) Mode() fs.FileMode {
+	return i.mode
+}
+
+func (i *memoryFileInfo) ModTime() time.Time {
+	return i.modTime
+}
+
+func (i *memoryFileInfo) IsDir() bool {
+	return i.mode.IsDir()
+}
+
+func (i *memoryFileInfo) Sys() any {
+	return nil
 }
 
 type MemoryFileNode struct {
@@ -226,27 +280,15 @@ func (f *MemoryFileNode) Delete() bool {
 	}
 }
 
-func (f *MemoryFileNode) copy(modTime time.Time) *MemoryFileNode {
+func (f *MemoryFileNode) copy(parent *MemoryDirNode, modTime time.Time) *MemoryFileNode {
 	return &MemoryFileNode{
-		parent:   f.parent,
+		parent:   parent,
 		name:     f.name,
 		content:  f.content,
 		modeTime: modTime,
 	}
 }
 
-func NewFileOrDirNotExists(path string) error {
-	return fmt.Errorf("%w, path: %s", fs.ErrNotExist, path)
-}
-
-func NewNotDir(path string) error {
-	return fmt.Errorf("%w, the input path \"%s\" is not a dir", fs.ErrInvalid, path)
-}
-
-func NewNotFile(path string) error {
-	return fmt.Errorf("%w, the input path \"%s\" is not a file", fs.ErrInvalid, path)
-}
-
 func (fs *MemoryFS) IsCaseSensitive() bool {
 	return fs.caseSensitive
 }
@@ -261,7 +303,7 @@ func (fs *MemoryFS) normName(name string) string {
 
 func (fs *MemoryFS) locate(path string, dirOfFile bool) (*MemoryDirNode, *MemoryFileNode) {
 	parts := strings.Split(path, "/")
-	node := fs.current
+	node := fs.root
 	var child *MemoryDirNode
 	var file *MemoryFileNode
 	for i, part := range parts {
@@ -296,40 +338,42 @@ func isFilePath(path string) bool {
 	return path != "" && !strings.HasSuffix(path, "/")
 }
 
+// resolve returns the cleaned absolute path without the trailing slash
 func (fs *MemoryFS) resolve(path string) string {
-	for {
-		if strings.HasSuffix(path, "/") {
-			path = path[0 : len(path)-1]
-		} else {
-			break
-		}
-	}
 	path = fs.normName(path)
-	if strings.HasPrefix(path, "/") {
-		return path
-	} else {
-		currentPath := fs.current.FullPath()
-		if currentPath == "/" {
-			return currentPath + path
-		} else {
-			return currentPath + "/" + path
-		}
+	if !strings.HasPrefix(path, "/") {
+		path = fs.current.FullPath() + "/" + path
 	}
+	return idpath.Clean(path)
 }
 
 func (fs *MemoryFS) Delete(path string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	isDir := strings.HasSuffix(path, "/")
 	path = fs.resolve(path)
 	dir, file := fs.locate(path, false)
 	if dir != nil {
 		if file != nil {
+			if isDir {
+				return NewNotDir(path)
+			}
 			file.Delete()
 		} else {
 			if dir == fs.root {
+				for _, child := range dir.children {
+					defer fs.watchers.notify(child.FullPath(), Remove)
+				}
+				for _, file := range dir.files {
+					defer fs.watchers.notify(file.FullPath(), Remove)
+				}
 				dir.Clean()
+				return nil
 			} else {
 				dir.Delete()
 			}
 		}
+		fs.watchers.notify(path, Remove)
 		return nil
 	} else {
 		return NewFileOrDirNotExists(path)
@@ -337,6 +381,8 @@ func (fs *MemoryFS) Delete(path string) error {
 }
 
 func (fs *MemoryFS) ReadDir(dirPath string) ([]fs.FileInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	dirPath = fs.resolve(dirPath)
 	dir, file := fs.locate(dirPath, false)
 	if dir == nil {
@@ -345,37 +391,56 @@ func (fs *MemoryFS) ReadDir(dirPath string) ([]fs.FileInfo, error) {
 	if file != nil {
 		return nil, NewNotDir(dirPath)
 	}
-	nodes := make([]FileInfo, 0, len(dir.children) + len(dir.files))
+	nodes := make([]FileInfo, 0, len(dir.children)+len(dir.files))
 	for _, child := range dir.children {
-		nodes = append(nodes, child)
+		nodes = append(nodes, newMemoryFileInfo(child))
 	}
 	for _, file := range dir.files {
-		nodes = append(nodes, file)
+		nodes = append(nodes, newMemoryFileInfo(file))
 	}
 	return nodes, nil
 }
 
-func (fs *MemoryFS) ReadFile(filePath string, encoding string) (string, error) {
+func (fs *MemoryFS) ReadFileBytes(filePath string) ([]byte, error) {
 	if !isFilePath(filePath) {
-		return "", NewNotFile(filePath)
+		return nil, NewNotFile(filePath)
 	}
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	filePath = fs.resolve(filePath)
 	dir, file := fs.locate(filePath, false)
 	if dir == nil {
-		return "", NewFileOrDirNotExists(filePath)
+		return nil, NewFileOrDirNotExists(filePath)
 	}
 	if file == nil {
-		return "", NewNotFile(filePath)
+		return nil, NewNotFile(filePath)
 	}
-	return file.content, nil
+	return []byte(file.content), nil
+}
+
+func (fs *MemoryFS) ReadFile(filePath string, encoding string) (string, error) {
+	data, err := fs.ReadFileBytes(filePath)
+	if err != nil {
+		return "", err
+	}
+	return DecodeText(data, encoding)
+}
+
+func (fs *MemoryFS) WriteFileBytes(filePath string, data []byte) error {
+	return fs.WriteFile(filePath, string(data))
 }
 
 func (fs *MemoryFS) WriteFile(filePath string, fileText string) error {
 	if !isFilePath(filePath) {
 		return NewNotFile(filePath)
 	}
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	filePath = fs.resolve(filePath)
 	dir, file := fs.locate(filePath, true)
+	if dir != nil && file == nil && dir.FullPath() == filePath {
+		return NewNotFile(filePath)
+	}
 	if dir == nil {
 		dirPath := dirName(filePath)
 		if dirPath == "" {
@@ -390,6 +455,7 @@ func (fs *MemoryFS) WriteFile(filePath string, fileText string) error {
 		file.parent.size += sizeDiff
 		file.modeTime = now
 		file.parent.modeTime = now
+		fs.watchers.notify(filePath, Write)
 	} else {
 		fileName := baseName(filePath)
 		if fileName == "" {
@@ -401,9 +467,13 @@ func (fs *MemoryFS) WriteFile(filePath string, fileText string) error {
 			content:  fileText,
 			modeTime: now,
 		}
+		if dir.files == nil {
+			dir.files = make(map[string]*MemoryFileNode)
+		}
 		dir.files[fileName] = file
 		dir.size += file.Size()
 		dir.modeTime = now
+		fs.watchers.notify(filePath, Create)
 	}
 	return nil
 }
@@ -413,8 +483,17 @@ func (fs *MemoryFS) mkdir(dirPath string) (*MemoryDirNode, error) {
 	node := fs.root
 	parts := strings.Split(dirPath, "/")
 	now := time.Now()
+	var created []string
+	defer func() {
+		for _, path := range created {
+			fs.watchers.notify(path, Create)
+		}
+	}()
 	for _, part := range parts {
 		if part != "" {
+			if _, isFile := node.files[part]; isFile {
+				return nil, NewNotDir(dirPath)
+			}
 			dir, found := node.children[part]
 			if !found {
 				dir = &MemoryDirNode{
@@ -425,9 +504,10 @@ func (fs *MemoryFS) mkdir(dirPath string) (*MemoryDirNode, error) {
 				}
 				if node.children == nil {
 					node.children = make(map[string]*MemoryDirNode)
-					node.children[dir.name] = dir
-					node.modeTime = now
 				}
+				node.children[dir.name] = dir
+				node.modeTime = now
+				created = append(created, dir.FullPath())
 			}
 			node = dir
 		}
@@ -436,13 +516,15 @@ func (fs *MemoryFS) mkdir(dirPath string) (*MemoryDirNode, error) {
 }
 
 func (fs *MemoryFS) Mkdir(dirPath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	_, err := fs.mkdir(dirPath)
 	return err
 }
 
+// copy copies or moves the file or the directory to the dest path, the directories are merged into the existing ones
 func (fs *MemoryFS) copy(srcPath string, destPath string, remove bool) error {
 	isSrcDir := strings.HasSuffix(srcPath, "/")
-	isDestDir := strings.HasSuffix(destPath, "/")
 	srcPath = fs.resolve(srcPath)
 	destPath = fs.resolve(destPath)
 	srcDir, srcFile := fs.locate(srcPath, false)
@@ -450,60 +532,85 @@ func (fs *MemoryFS) copy(srcPath string, destPath string, remove bool) error {
 	if srcDir == nil {
 		return NewFileOrDirNotExists(srcPath)
 	}
+	destDir, destFile := fs.locate(destPath, false)
 	if srcFile != nil {
 		if isSrcDir {
-			return NewFileOrDirNotExists(srcPath)
+			return NewNotDir(srcPath)
 		}
-		destDir, destFile := fs.locate(destPath, false)
-		if destFile != nil && isDestDir {
-			return NewNotDir(destPath)
+		if destDir != nil && destFile == nil {
+			return NewNotFile(destPath)
 		}
-		if destDir == nil {
-			destDir, _ = fs.mkdir(destPath)
+		if destFile == srcFile {
+			return nil
 		}
+		op := Write
 		if destFile == nil {
+			op = Create
+			destDir, err := fs.mkdir(dirName(destPath))
+			if err != nil {
+				return err
+			}
 			destFile = &MemoryFileNode{
-				parent:   destDir,
-				name:     srcFile.name,
-				content:  srcFile.content,
-				modeTime: now,
+				parent: destDir,
+				name:   baseName(destPath),
+			}
+			if destDir.files == nil {
+				destDir.files = make(map[string]*MemoryFileNode)
 			}
 			destDir.files[destFile.name] = destFile
-			destDir.size += destFile.Size()
-		} else {
-			sizeDiff := srcFile.Size() - destFile.Size()
-			destFile.content = srcFile.content
-			destFile.modeTime = now
-			destDir.size += sizeDiff
 		}
-		destDir.modeTime = now
+		destFile.parent.size += srcFile.Size() - destFile.Size()
+		destFile.content = srcFile.content
+		destFile.modeTime = now
+		destFile.parent.modeTime = now
 		if remove {
 			srcFile.Delete()
+			fs.watchers.notify(srcPath, Rename)
 		}
+		fs.watchers.notify(destPath, op)
 	} else {
-		destDir, destFile := fs.locate(destPath, false)
 		if destFile != nil {
 			return NewNotDir(destPath)
 		}
+		if destDir == srcDir {
+			return nil
+		}
+		if srcDir == fs.root || strings.HasPrefix(destPath, srcDir.FullPath()+"/") {
+			return newCopyIntoItself(srcPath)
+		}
 		if destDir == nil {
-			destDir, _ = fs.mkdir(destPath)
+			var err error
+			destDir, err = fs.mkdir(destPath)
+			if err != nil {
+				return err
+			}
 		}
+		srcFiles := srcDir.allFiles(nil)
+		srcPrefix := srcDir.FullPath()
 		if !remove {
-			srcDir = srcDir.deepCopy(now)
+			srcDir = srcDir.deepCopy(nil, now)
+		} else {
+			srcDir.Delete()
+			fs.watchers.notify(srcPath, Rename)
 		}
 		destDir.merge(srcDir, true)
-		if remove {
-			srcDir.Delete()
+		destDir.modeTime = now
+		for _, file := range srcFiles {
+			fs.watchers.notify(destPath+strings.TrimPrefix(file, srcPrefix), Create)
 		}
 	}
 	return nil
 }
 
-func (fs *MemoryFS) Move(srcPath string, destPath string, remove bool) error {
+func (fs *MemoryFS) Move(srcPath string, destPath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	return fs.copy(srcPath, destPath, true)
 }
 
-func (fs *MemoryFS) Copy(srcPath string, destPath string, remove bool) error {
+func (fs *MemoryFS) Copy(srcPath string, destPath string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
 	return fs.copy(srcPath, destPath, false)
 }
 
@@ -511,6 +618,8 @@ func (fs *MemoryFS) FileExists(filePath string) (bool, error) {
 	if !isFilePath(filePath) {
 		return false, nil
 	} else {
+		fs.mu.RLock()
+		defer fs.mu.RUnlock()
 		filePath = fs.resolve(filePath)
 		_, file := fs.locate(filePath, false)
 		return file != nil, nil
@@ -518,35 +627,114 @@ func (fs *MemoryFS) FileExists(filePath string) (bool, error) {
 }
 
 func (fs *MemoryFS) DirectoryExists(dirPath string) (bool, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	dirPath = fs.resolve(dirPath)
 	dir, file := fs.locate(dirPath, false)
 	return dir != nil && file == nil, nil
 }
 
+// Watch fires the events as the file system changes, the callback is called by the goroutine changing it
+// while the file system is locked, so it must not call back into it.
+// The paths don't need to exist.
+func (fs *MemoryFS) Watch(path string, recursive bool, callback func(Event)) (func(), error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	return fs.watchers.add(fs.resolve(path), recursive, callback), nil
+}
+
+func (fs *MemoryFS) Stat(path string) (FileInfo, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
+	isDir := strings.HasSuffix(path, "/")
+	path = fs.resolve(path)
+	dir, file := fs.locate(path, false)
+	if dir == nil {
+		return nil, NewFileOrDirNotExists(path)
+	}
+	if file != nil {
+		if isDir {
+			return nil, NewNotDir(path)
+		}
+		return newMemoryFileInfo(file), nil
+	}
+	return newMemoryFileInfo(dir), nil
+}
+
+func (fs *MemoryFS) SetModTime(path string, modTime time.Time) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+	path = fs.resolve(path)
+	dir, file := fs.locate(path, false)
+	if dir == nil {
+		return NewFileOrDirNotExists(path)
+	}
+	if file != nil {
+		file.modeTime = modTime
+	} else {
+		dir.modeTime = modTime
+	}
+	return nil
+}
+
+var _ ModTimeFileSystem = (*MemoryFS)(nil)
+
 func (fs *MemoryFS) Realpath(path string) (string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	return fs.resolve(path), nil
 }
 
 func (fs *MemoryFS) GetCurrentDirectory() (string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	return fs.current.FullPath(), nil
 }
 
 func isNotPattern(part string) bool {
-	return !strings.ContainsAny(part, "?*[{\\") 
+	return !strings.ContainsAny(part, "?*[{\\")
+}
+
+func (d *MemoryDirNode) allFiles(pathes []string) []string {
+	for _, file := range d.files {
+		pathes = append(pathes, file.FullPath())
+	}
+	for _, child := range d.children {
+		pathes = child.allFiles(pathes)
+	}
+	return pathes
 }
 
 func (fs *MemoryFS) _glob(node *MemoryDirNode, parts []string) ([]string, error) {
 	for i, part := range parts {
 		if part != "" {
-			if isNotPattern(part) {
+			last := i == len(parts)-1
+			if part == "**" {
+				// "**" matches any directories, including none of them
+				if last {
+					return node.allFiles(nil), nil
+				}
+				pathes, err := fs._glob(node, parts[i+1:])
+				if err != nil {
+					return nil, err
+				}
+				for _, dir := range node.children {
+					res, err := fs._glob(dir, parts[i:])
+					if err != nil {
+						return nil, err
+					}
+					pathes = append(pathes, res...)
+				}
+				return pathes, nil
+			} else if isNotPattern(part) {
 				dir, found := node.children[part]
 				if found {
 					node = dir
 					continue
 				}
 				file, found := node.files[part]
-				if found && i == len(parts) - 1 {
-					return []string {file.FullPath()}, nil
+				if found && last {
+					return []string{file.FullPath()}, nil
 				} else {
 					return nil, nil
 				}
@@ -558,7 +746,7 @@ func (fs *MemoryFS) _glob(node *MemoryDirNode, parts []string) ([]string, error)
 				var pathes []string
 				for dirName, dir := range node.children {
 					if g.Match(dirName) {
-						res, err := fs._glob(dir, parts[i + 1:])
+						res, err := fs._glob(dir, parts[i+1:])
 						if err != nil {
 							return nil, err
 						} else if res != nil {
@@ -566,9 +754,11 @@ func (fs *MemoryFS) _glob(node *MemoryDirNode, parts []string) ([]string, error)
 						}
 					}
 				}
-				for fileName, file := range node.files {
-					if g.Match(fileName) {
-						pathes = append(pathes, file.FullPath())
+				if last {
+					for fileName, file := range node.files {
+						if g.Match(fileName) {
+							pathes = append(pathes, file.FullPath())
+						}
 					}
 				}
 				return pathes, nil
@@ -579,31 +769,35 @@ func (fs *MemoryFS) _glob(node *MemoryDirNode, parts []string) ([]string, error)
 }
 
 func (fs *MemoryFS) glob(pattern string) ([]string, error) {
-	for {
-		if strings.HasSuffix(pattern, "/") {
-			pattern = pattern[0 : len(pattern)-1]
-		} else {
-			break
-		}
-	}
-	var node *MemoryDirNode
-	if strings.HasPrefix(pattern, "/") {
-		node = fs.root
-	} else {
-		node = fs.current
+	// only the files are matched, but the pattern with a trailing slash matches the directories
+	if strings.HasSuffix(pattern, "/") {
+		return nil, nil
 	}
-	parts := strings.Split(pattern, "/")
-	return fs._glob(node, parts)
+	parts := strings.Split(fs.resolve(pattern), "/")
+	return fs._glob(fs.root, parts)
 }
 
+// Glob returns the sorted paths of the files matching any of the patterns, "**" matches any directories.
 func (fs *MemoryFS) Glob(patterns []string) ([]string, error) {
+	fs.mu.RLock()
+	defer fs.mu.RUnlock()
 	var pathes []string
+	found := make(map[string]bool)
 	for _, pattern := range patterns {
 		res, err := fs.glob(pattern)
 		if err != nil {
 			return nil, err
 		}
-		pathes = append(pathes, res...)
+		for _, path := range res {
+			if !found[path] {
+				found[path] = true
+				pathes = append(pathes, path)
+			}
+		}
 	}
+	sort.Strings(pathes)
 	return pathes, nil
-}
\ No newline at end of file
+}
+
+var _ FileSystem = (*MemoryFS)(nil)
+var _ Watcher = (*MemoryFS)(nil)
@@ -0,0 +1,665 @@
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	idpath "path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// SandboxFS confines the file system to a directory of the host machine, the paths escaping it
+// by ".." or by the symbolic links are rejected with ErrOutOfSandbox.
+type SandboxFS struct {
+	// absolute host path of the root, with the symbolic links resolved
+	root string
+	// absolute slash path of sandbox
+	current  string
+	readOnly bool
+	// watchMu guards notify, the inotify instance shared by the watches
+	watchMu sync.Mutex
+	notify  *inotify
+}
+
+type SandboxOption func(s *SandboxFS)
+
+// WithReadOnly makes the write methods of the sandbox fail with ErrReadOnly.
+func WithReadOnly() SandboxOption {
+	return func(s *SandboxFS) {
+		s.readOnly = true
+	}
+}
+
+// NewSandboxFS returns a file system confined to the root directory of the host machine,
+// which is mounted at "/" and is also the current directory.
+func NewSandboxFS(root string, opts ...SandboxOption) (*SandboxFS, error) {
+	absRoot, err := filepath.Abs(root)
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve the sandbox root \"%s\", %w", root, err)
+	}
+	realRoot, err := filepath.EvalSymlinks(absRoot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to access the sandbox root \"%s\", %w", root, err)
+	}
+	info, err := os.Stat(realRoot)
+	if err != nil {
+		return nil, fmt.Errorf("unable to access the sandbox root \"%s\", %w", root, err)
+	}
+	if !info.IsDir() {
+		return nil, NewNotDir(root)
+	}
+	s := &SandboxFS{
+		root:    realRoot,
+		current: "/",
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s, nil
+}
+
+func genTestFilename(str string) string {
+	flip := true
+	return strings.Map(func(r rune) rune {
+		if flip {
+			if unicode.IsLower(r) {
+				u := unicode.ToUpper(r)
+				if unicode.ToLower(u) == r {
+					r = u
+					flip = false
+				}
+			} else if unicode.IsUpper(r) {
+				l := unicode.ToLower(r)
+				if unicode.ToUpper(l) == r {
+					r = l
+					flip = false
+				}
+			}
+		}
+		return r
+	}, str)
+}
+
+func CheckFileSystemCaseSensitive() bool {
+	dir, err := os.MkdirTemp("", "test")
+	if err != nil {
+		panic(fmt.Errorf("could not determine the case-sensitivity of the filesystem, unable to create the temp directory, %w", err))
+	}
+	defer os.Remove(dir)
+	alt := filepath.Join(filepath.Dir(dir), genTestFilename(filepath.Base(dir)))
+
+	dInfo, err := os.Stat(dir)
+	if err != nil {
+		panic(fmt.Errorf("could not determine the case-sensitivity of the filesystem, %w", err))
+	}
+
+	aInfo, err := os.Stat(alt)
+	if err != nil {
+		// If the file doesn't exists, assume we are on a case-sensitive filesystem.
+		if os.IsNotExist(err) {
+			return true
+		}
+
+		panic(fmt.Errorf("could not determine the case-sensitivity of the filesystem, %w", err))
+	}
+
+	return !os.SameFile(dInfo, aInfo)
+}
+
+var FSCaseSensitive = CheckFileSystemCaseSensitive()
+
+// the max number of the symbolic links followed to check a path, like the ELOOP limit of linux
+const maxSymlinks = 40
+
+// sandboxPath resolves the path against the current directory, ".." must not go above the root
+func (s *SandboxFS) sandboxPath(path string) (string, error) {
+	path = filepath.ToSlash(path)
+	if !strings.HasPrefix(path, "/") {
+		path = s.current + "/" + path
+	}
+	depth := 0
+	for _, part := range strings.Split(path, "/") {
+		switch part {
+		case "", ".":
+		case "..":
+			depth--
+			if depth < 0 {
+				return "", newOutOfSandbox(path)
+			}
+		default:
+			depth++
+		}
+	}
+	return idpath.Clean(path), nil
+}
+
+func (s *SandboxFS) inRoot(hostPath string) bool {
+	rel, err := filepath.Rel(s.root, hostPath)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) && !filepath.IsAbs(rel)
+}
+
+// checkSymlinks checks the symbolic links on the host path resolve inside the root,
+// including the dangling ones, which would create their targets on write.
+func (s *SandboxFS) checkSymlinks(hostPath string, path string) error {
+	for i := 0; i < maxSymlinks; i++ {
+		real, err := filepath.EvalSymlinks(hostPath)
+		if err == nil {
+			if !s.inRoot(real) {
+				return newOutOfSandbox(path)
+			}
+			return nil
+		}
+		info, lstatErr := os.Lstat(hostPath)
+		if lstatErr == nil && info.Mode()&fs.ModeSymlink == 0 && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		if lstatErr == nil && info.Mode()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(hostPath)
+			if err != nil {
+				return err
+			}
+			if !filepath.IsAbs(target) {
+				target = filepath.Join(filepath.Dir(hostPath), target)
+			}
+			hostPath = target
+			continue
+		}
+		parent := filepath.Dir(hostPath)
+		if parent == hostPath {
+			return nil
+		}
+		hostPath = parent
+	}
+	return newOutOfSandbox(path)
+}
+
+// resolveOsPath returns the host path of the sandbox path, after checking it doesn't escape the root
+func (s *SandboxFS) resolveOsPath(path string) (string, error) {
+	sandboxPath, err := s.sandboxPath(path)
+	if err != nil {
+		return "", err
+	}
+	hostPath := filepath.Join(s.root, filepath.FromSlash(sandboxPath))
+	err = s.checkSymlinks(hostPath, path)
+	if err != nil {
+		return "", err
+	}
+	return hostPath, nil
+}
+
+// toSandboxPath converts the host path inside the root to the sandbox path
+func (s *SandboxFS) toSandboxPath(hostPath string) string {
+	rel, err := filepath.Rel(s.root, hostPath)
+	if err != nil || rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
+
+func (s *SandboxFS) checkWritable() error {
+	if s.readOnly {
+		return ErrReadOnly
+	}
+	return nil
+}
+
+// stat returns nil if the path does not exist
+func (s *SandboxFS) stat(hostPath string) (fs.FileInfo, error) {
+	info, err := os.Stat(hostPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	return info, err
+}
+
+func (s *SandboxFS) IsCaseSensitive() bool {
+	return FSCaseSensitive
+}
+
+func (s *SandboxFS) Delete(path string) error {
+	err := s.checkWritable()
+	if err != nil {
+		return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+	}
+	hostPath, err := s.resolveOsPath(path)
+	if err != nil {
+		return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+	}
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+	}
+	if !info.IsDir() && strings.HasSuffix(path, "/") {
+		return NewNotDir(path)
+	}
+	if hostPath == s.root {
+		// the root is kept, like MemoryFS does
+		entries, err := os.ReadDir(hostPath)
+		if err != nil {
+			return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+		}
+		for _, entry := range entries {
+			err = os.RemoveAll(filepath.Join(hostPath, entry.Name()))
+			if err != nil {
+				return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+			}
+		}
+		return nil
+	}
+	err = os.RemoveAll(hostPath)
+	if err != nil {
+		return fmt.Errorf("unable to delete \"%s\", %w", path, err)
+	}
+	return nil
+}
+
+// ReadDir returns the direct children of the directory.
+func (s *SandboxFS) ReadDir(dirPath string) ([]fs.FileInfo, error) {
+	hostPath, err := s.resolveOsPath(dirPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+	}
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+	}
+	if !info.IsDir() {
+		return nil, NewNotDir(dirPath)
+	}
+	entries, err := os.ReadDir(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+	}
+	result := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("unable to read dir \"%s\", %w", dirPath, err)
+		}
+		result = append(result, info)
+	}
+	return result, nil
+}
+
+func (s *SandboxFS) ReadFileBytes(filePath string) ([]byte, error) {
+	hostPath, err := s.resolveOsPath(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file \"%s\", %w", filePath, err)
+	}
+	info, err := s.stat(hostPath)
+	if err == nil && info != nil && (info.IsDir() || strings.HasSuffix(filePath, "/")) {
+		return nil, NewNotFile(filePath)
+	}
+	bytes, err := os.ReadFile(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read file \"%s\", %w", filePath, err)
+	}
+	return bytes, nil
+}
+
+func (s *SandboxFS) ReadFile(filePath string, encoding string) (string, error) {
+	bytes, err := s.ReadFileBytes(filePath)
+	if err != nil {
+		return "", err
+	}
+	text, err := DecodeText(bytes, encoding)
+	if err != nil {
+		return "", fmt.Errorf("unable to read file \"%s\", %w", filePath, err)
+	}
+	return text, nil
+}
+
+func (s *SandboxFS) WriteFile(filePath string, fileText string) error {
+	return s.WriteFileBytes(filePath, []byte(fileText))
+}
+
+func (s *SandboxFS) WriteFileBytes(filePath string, data []byte) error {
+	err := s.checkWritable()
+	if err != nil {
+		return fmt.Errorf("unable to write file \"%s\", %w", filePath, err)
+	}
+	hostPath, err := s.resolveOsPath(filePath)
+	if err != nil {
+		return fmt.Errorf("unable to write file \"%s\", %w", filePath, err)
+	}
+	if strings.HasSuffix(filePath, "/") {
+		return NewNotFile(filePath)
+	}
+	if info, err := s.stat(hostPath); err == nil && info != nil && info.IsDir() {
+		return NewNotFile(filePath)
+	}
+	err = os.WriteFile(hostPath, data, 0770)
+	if err != nil {
+		return fmt.Errorf("unable to write file \"%s\", %w", filePath, err)
+	}
+	return nil
+}
+
+// mkdirAll makes the directory and its missing parents, the existing files on the way fail with ErrNotDir
+func (s *SandboxFS) mkdirAll(hostPath string, dirPath string) error {
+	for p := hostPath; s.inRoot(p); p = filepath.Dir(p) {
+		// the paths under a file fail with ENOTDIR instead of ENOENT
+		info, err := os.Stat(p)
+		if err != nil {
+			continue
+		}
+		if !info.IsDir() {
+			return NewNotDir(dirPath)
+		}
+		break
+	}
+	return os.MkdirAll(hostPath, 0770)
+}
+
+func (s *SandboxFS) Mkdir(dirPath string) error {
+	err := s.checkWritable()
+	if err != nil {
+		return fmt.Errorf("unable to make dir \"%s\", %w", dirPath, err)
+	}
+	hostPath, err := s.resolveOsPath(dirPath)
+	if err != nil {
+		return fmt.Errorf("unable to make dir \"%s\", %w", dirPath, err)
+	}
+	err = s.mkdirAll(hostPath, dirPath)
+	if err != nil {
+		return fmt.Errorf("unable to make dir \"%s\", %w", dirPath, err)
+	}
+	return nil
+}
+
+func copyFile(srcPath string, destPath string) error {
+	src, err := os.Open(srcPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	dest, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0770)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(dest, src)
+	if closeErr := dest.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// copyDir merges the source directory into the dest one, the symbolic links are followed
+// only if they resolve inside the root, the ones to the directories are skipped.
+func (s *SandboxFS) copyDir(srcPath string, destPath string) error {
+	return filepath.WalkDir(srcPath, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(srcPath, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(destPath, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0770)
+		}
+		if d.Type()&fs.ModeSymlink != 0 {
+			err = s.checkSymlinks(path, s.toSandboxPath(path))
+			if err != nil {
+				return err
+			}
+			info, err := os.Stat(path)
+			if err != nil || info.IsDir() {
+				return err
+			}
+		}
+		return copyFile(path, target)
+	})
+}
+
+// copy copies or moves the file or the directory to the dest path, the directories are merged into the existing ones
+func (s *SandboxFS) copy(srcPath string, destPath string, remove bool) error {
+	err := s.checkWritable()
+	if err != nil {
+		return err
+	}
+	hostSrcPath, err := s.resolveOsPath(srcPath)
+	if err != nil {
+		return err
+	}
+	hostDestPath, err := s.resolveOsPath(destPath)
+	if err != nil {
+		return err
+	}
+	srcInfo, err := os.Stat(hostSrcPath)
+	if err != nil {
+		return err
+	}
+	destInfo, err := s.stat(hostDestPath)
+	if err != nil {
+		return err
+	}
+	if hostSrcPath == hostDestPath {
+		return nil
+	}
+	if !srcInfo.IsDir() {
+		if strings.HasSuffix(srcPath, "/") {
+			return NewNotDir(srcPath)
+		}
+		if destInfo != nil && destInfo.IsDir() {
+			return NewNotFile(destPath)
+		}
+		err = s.mkdirAll(filepath.Dir(hostDestPath), destPath)
+		if err != nil {
+			return err
+		}
+		if remove {
+			return os.Rename(hostSrcPath, hostDestPath)
+		}
+		return copyFile(hostSrcPath, hostDestPath)
+	}
+	if destInfo != nil && !destInfo.IsDir() {
+		return NewNotDir(destPath)
+	}
+	if hostSrcPath == s.root || strings.HasPrefix(hostDestPath, hostSrcPath+string(filepath.Separator)) {
+		return newCopyIntoItself(srcPath)
+	}
+	if remove && destInfo == nil {
+		err = s.mkdirAll(filepath.Dir(hostDestPath), destPath)
+		if err != nil {
+			return err
+		}
+		return os.Rename(hostSrcPath, hostDestPath)
+	}
+	err = s.mkdirAll(hostDestPath, destPath)
+	if err != nil {
+		return err
+	}
+	err = s.copyDir(hostSrcPath, hostDestPath)
+	if err != nil {
+		return err
+	}
+	if remove {
+		return os.RemoveAll(hostSrcPath)
+	}
+	return nil
+}
+
+func (s *SandboxFS) Move(srcPath string, destPath string) error {
+	err := s.copy(srcPath, destPath, true)
+	if err != nil {
+		return fmt.Errorf("unable to move source path \"%s\" to dest path \"%s\", %w", srcPath, destPath, err)
+	}
+	return nil
+}
+
+func (s *SandboxFS) Copy(srcPath string, destPath string) error {
+	err := s.copy(srcPath, destPath, false)
+	if err != nil {
+		return fmt.Errorf("unable to copy source path \"%s\" to dest path \"%s\", %w", srcPath, destPath, err)
+	}
+	return nil
+}
+
+// exists returns the info of the path, or nil if it does not exist or is out of the sandbox
+func (s *SandboxFS) exists(path string) (fs.FileInfo, error) {
+	hostPath, err := s.resolveOsPath(path)
+	if errors.Is(err, ErrOutOfSandbox) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.stat(hostPath)
+}
+
+func (s *SandboxFS) FileExists(filePath string) (bool, error) {
+	info, err := s.exists(filePath)
+	if err != nil {
+		return false, fmt.Errorf("unable to check the file \"%s\", %w", filePath, err)
+	}
+	return info != nil && !info.IsDir() && !strings.HasSuffix(filePath, "/"), nil
+}
+
+func (s *SandboxFS) DirectoryExists(dirPath string) (bool, error) {
+	info, err := s.exists(dirPath)
+	if err != nil {
+		return false, fmt.Errorf("unable to check the dir \"%s\", %w", dirPath, err)
+	}
+	return info != nil && info.IsDir(), nil
+}
+
+func (s *SandboxFS) Stat(path string) (fs.FileInfo, error) {
+	hostPath, err := s.resolveOsPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+	}
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+	}
+	if !info.IsDir() && strings.HasSuffix(path, "/") {
+		return nil, NewNotDir(path)
+	}
+	return info, nil
+}
+
+// Lstat returns the info of the symbolic link itself, which may point out of the sandbox.
+func (s *SandboxFS) Lstat(path string) (fs.FileInfo, error) {
+	sandboxPath, err := s.sandboxPath(path)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+	}
+	hostPath := filepath.Join(s.root, filepath.FromSlash(sandboxPath))
+	if hostPath != s.root {
+		// only the parent must resolve inside the root
+		err = s.checkSymlinks(filepath.Dir(hostPath), path)
+		if err != nil {
+			return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+		}
+	}
+	info, err := os.Lstat(hostPath)
+	if err != nil {
+		return nil, fmt.Errorf("unable to stat \"%s\", %w", path, err)
+	}
+	return info, nil
+}
+
+var _ LstatFileSystem = (*SandboxFS)(nil)
+
+func (s *SandboxFS) SetModTime(path string, modTime time.Time) error {
+	err := s.checkWritable()
+	if err != nil {
+		return fmt.Errorf("unable to set the modification time of \"%s\", %w", path, err)
+	}
+	hostPath, err := s.resolveOsPath(path)
+	if err != nil {
+		return fmt.Errorf("unable to set the modification time of \"%s\", %w", path, err)
+	}
+	err = os.Chtimes(hostPath, time.Time{}, modTime)
+	if err != nil {
+		return fmt.Errorf("unable to set the modification time of \"%s\", %w", path, err)
+	}
+	return nil
+}
+
+var _ ModTimeFileSystem = (*SandboxFS)(nil)
+
+// Realpath returns the sandbox path with the symbolic links resolved.
+func (s *SandboxFS) Realpath(path string) (string, error) {
+	hostPath, err := s.resolveOsPath(path)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the real path of \"%s\", %w", path, err)
+	}
+	real, err := filepath.EvalSymlinks(hostPath)
+	if err != nil {
+		return "", fmt.Errorf("unable to resolve the real path of \"%s\", %w", path, err)
+	}
+	return s.toSandboxPath(real), nil
+}
+
+func (s *SandboxFS) GetCurrentDirectory() (string, error) {
+	return s.current, nil
+}
+
+// Glob returns the sorted paths of the files matching any of the patterns, "**" matches any directories.
+// The directories linked by the symbolic links are not walked, the links to the files out of the root are skipped.
+func (s *SandboxFS) Glob(patterns []string) ([]string, error) {
+	m, err := newGlobMatcher(patterns, s.current, !s.IsCaseSensitive())
+	if err != nil {
+		return nil, err
+	}
+	var paths []string
+	for _, root := range m.roots() {
+		hostRoot, err := s.resolveOsPath(root)
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob, %w", err)
+		}
+		realRoot, err := filepath.EvalSymlinks(hostRoot)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob, %w", err)
+		}
+		err = filepath.WalkDir(realRoot, func(hostPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				return nil
+			}
+			if d.Type()&fs.ModeSymlink != 0 {
+				// only the links to the files inside the root are matched
+				real, err := filepath.EvalSymlinks(hostPath)
+				if err != nil || !s.inRoot(real) {
+					return nil
+				}
+				if info, err := os.Stat(real); err != nil || info.IsDir() {
+					return nil
+				}
+			}
+			rel, err := filepath.Rel(realRoot, hostPath)
+			if err != nil {
+				return err
+			}
+			if path := idpath.Join(root, filepath.ToSlash(rel)); m.Match(path) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("unable to glob, %w", err)
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+var _ FileSystem = (*SandboxFS)(nil)
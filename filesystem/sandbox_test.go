@@ -0,0 +1,109 @@
+package filesystem_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestSandboxFSEscape(t *testing.T) {
+	dir := t.TempDir()
+	root := filepath.Join(dir, "root")
+	outside := filepath.Join(dir, "outside")
+	for _, d := range []string{filepath.Join(root, "src"), outside} {
+		if err := os.MkdirAll(d, 0770); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for path, content := range map[string]string{
+		filepath.Join(root, "src", "a.ts"):   "export const a = 1;",
+		filepath.Join(outside, "secret.txt"): "secret",
+	} {
+		if err := os.WriteFile(path, []byte(content), 0660); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for link, target := range map[string]string{
+		filepath.Join(root, "secret.txt"): filepath.Join(outside, "secret.txt"),
+		filepath.Join(root, "outside"):    outside,
+		filepath.Join(root, "dangling"):   filepath.Join(outside, "created.txt"),
+		filepath.Join(root, "alias.ts"):   filepath.Join("src", "a.ts"),
+	} {
+		if err := os.Symlink(target, link); err != nil {
+			t.Skipf("symbolic links are not supported, %v", err)
+		}
+	}
+	sfs, err := filesystem.NewSandboxFS(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, path := range []string{"/../outside/secret.txt", "src/../../outside/secret.txt", "/secret.txt", "/outside/secret.txt"} {
+		_, err = sfs.ReadFile(path, "utf-8")
+		test.AssertEqual(t, true, errors.Is(err, filesystem.ErrOutOfSandbox), path+": ")
+		exists, err := sfs.FileExists(path)
+		test.AssertEqual(t, nil, err, path+": ")
+		test.AssertEqual(t, false, exists, path+": ")
+	}
+	for _, path := range []string{"/secret.txt", "/outside/new.txt", "/dangling"} {
+		err = sfs.WriteFile(path, "pwned")
+		test.AssertEqual(t, true, errors.Is(err, filesystem.ErrOutOfSandbox), path+": ")
+	}
+	content, err := os.ReadFile(filepath.Join(outside, "secret.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, "secret", string(content), "")
+	_, err = os.Stat(filepath.Join(outside, "created.txt"))
+	test.AssertEqual(t, true, errors.Is(err, os.ErrNotExist), "")
+	test.AssertEqual(t, true, errors.Is(sfs.Copy("/secret.txt", "/copy.txt"), filesystem.ErrOutOfSandbox), "")
+	test.AssertEqual(t, true, errors.Is(sfs.Delete("/outside/secret.txt"), filesystem.ErrOutOfSandbox), "")
+
+	_, err = sfs.Stat("/secret.txt")
+	test.AssertEqual(t, true, errors.Is(err, filesystem.ErrOutOfSandbox), "")
+	info, err := filesystem.Lstat(sfs, "/secret.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, true, info.Mode()&os.ModeSymlink != 0, "")
+	_, err = filesystem.Lstat(sfs, "/outside/secret.txt")
+	test.AssertEqual(t, true, errors.Is(err, filesystem.ErrOutOfSandbox), "")
+
+	content2, err := sfs.ReadFile("/alias.ts", "utf-8")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, "export const a = 1;", content2, "")
+	real, err := sfs.Realpath("/alias.ts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, "/src/a.ts", real, "")
+	paths, err := sfs.Glob([]string{"/**/*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 2, len(paths), "")
+}
+
+func TestSandboxFSReadOnly(t *testing.T) {
+	root := t.TempDir()
+	sfs, err := filesystem.NewSandboxFS(root, filesystem.WithReadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, true, errors.Is(sfs.WriteFile("/a.ts", ""), filesystem.ErrReadOnly), "")
+	test.AssertEqual(t, true, errors.Is(sfs.Mkdir("/src"), filesystem.ErrReadOnly), "")
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.AssertEqual(t, 0, len(entries), "")
+
+	_, err = filesystem.NewSandboxFS(filepath.Join(root, "missing"))
+	test.AssertEqual(t, true, errors.Is(err, filesystem.ErrNotExist), "")
+}
@@ -0,0 +1,122 @@
+package filesystem
+
+import (
+	"fmt"
+	"io/fs"
+	idpath "path"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+type FileInfo = fs.FileInfo
+
+func cleanHeadSlash(path string) string {
+	for {
+		if strings.HasPrefix(path, "/") {
+			path = path[1:]
+		} else {
+			break
+		}
+	}
+	return path
+}
+
+func dirName(filePath string) string {
+	i := strings.LastIndex(filePath, "/")
+	if i == -1 {
+		return ""
+	} else {
+		dir := filePath[0:i]
+		if dir == "" {
+			return "/"
+		} else {
+			return dir
+		}
+	}
+}
+
+func baseName(filePath string) string {
+	i := strings.LastIndex(filePath, "/")
+	if i == -1 {
+		return filePath
+	} else {
+		return filePath[i+1:]
+	}
+}
+
+// globMatcher matches the absolute slash paths of the files against the glob patterns
+type globMatcher struct {
+	globs []glob.Glob
+	// the static prefixes of the patterns, only the files under them can match
+	bases []string
+	fold  bool
+}
+
+// newGlobMatcher resolves the patterns against the current directory, "**" matches any directories,
+// including none of them, and the patterns with a trailing slash match no file.
+// The paths and the patterns are compared in lower case if fold is true.
+func newGlobMatcher(patterns []string, current string, fold bool) (*globMatcher, error) {
+	m := &globMatcher{fold: fold}
+	for _, pattern := range patterns {
+		if strings.HasSuffix(pattern, "/") {
+			continue
+		}
+		if !strings.HasPrefix(pattern, "/") {
+			pattern = current + "/" + pattern
+		}
+		pattern = idpath.Clean(pattern)
+		if fold {
+			pattern = strings.ToLower(pattern)
+		}
+		for _, p := range []string{pattern, strings.ReplaceAll(pattern, "/**/", "/")} {
+			g, err := glob.Compile(p, '/')
+			if err != nil {
+				return nil, fmt.Errorf("invalid glob pattern \"%s\", %w", pattern, err)
+			}
+			m.globs = append(m.globs, g)
+		}
+		m.bases = append(m.bases, globBase(pattern))
+	}
+	return m, nil
+}
+
+func globBase(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if !isNotPattern(part) {
+			return idpath.Clean("/" + strings.Join(parts[:i], "/"))
+		}
+	}
+	return idpath.Dir(pattern)
+}
+
+// roots returns the directories to walk, the bases not under the others
+func (m *globMatcher) roots() []string {
+	bases := append([]string(nil), m.bases...)
+	sort.Strings(bases)
+	var roots []string
+	for _, base := range bases {
+		if len(roots) > 0 {
+			last := roots[len(roots)-1]
+			if base == last || last == "/" || strings.HasPrefix(base, last+"/") {
+				continue
+			}
+		}
+		roots = append(roots, base)
+	}
+	return roots
+}
+
+func (m *globMatcher) Match(path string) bool {
+	if m.fold {
+		path = strings.ToLower(path)
+	}
+	for _, g := range m.globs {
+		if g.Match(path) {
+			return true
+		}
+	}
+	return false
+}
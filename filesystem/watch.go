@@ -0,0 +1,113 @@
+package filesystem
+
+import (
+	"strings"
+	"sync"
+)
+
+// EventOp describes the change of an Event.
+type EventOp uint32
+
+const (
+	// Create means the file or the directory is created.
+	Create EventOp = 1 << iota
+	// Write means the content of the file is changed.
+	Write
+	// Remove means the file or the directory is deleted.
+	Remove
+	// Rename means the file or the directory is moved away, its new path gets a Create event.
+	Rename
+)
+
+func (op EventOp) String() string {
+	var names []string
+	for _, o := range []struct {
+		op   EventOp
+		name string
+	}{{Create, "CREATE"}, {Write, "WRITE"}, {Remove, "REMOVE"}, {Rename, "RENAME"}} {
+		if op&o.op != 0 {
+			names = append(names, o.name)
+		}
+	}
+	return strings.Join(names, "|")
+}
+
+// Event is a change of the file system, Path is the absolute slash path of the changed file or directory.
+type Event struct {
+	Path string
+	Op   EventOp
+}
+
+// Watcher is implemented by the file systems able to notify the changes, MemoryFS fires the events
+// as it changes and SandboxFS uses inotify on linux.
+type Watcher interface {
+	// Watch calls the callback on the changes of the file, or of the directory and its direct children,
+	// or of all its descendants if recursive is true. The watched path itself being removed by the removal
+	// of an ancestor is reported as a Remove event of the path. The callback runs on an unspecified goroutine
+	// and must not block or call back into the file system. stop stops the watching.
+	Watch(path string, recursive bool, callback func(Event)) (stop func(), err error)
+}
+
+type watch struct {
+	path      string
+	recursive bool
+	callback  func(Event)
+}
+
+// match returns the event the watch receives for the event, if any
+func (w *watch) match(e Event) (Event, bool) {
+	prefix := w.path
+	if prefix != "/" {
+		prefix += "/"
+	}
+	switch {
+	case e.Path == w.path:
+		return e, true
+	case strings.HasPrefix(e.Path, prefix):
+		if w.recursive || !strings.Contains(e.Path[len(prefix):], "/") {
+			return e, true
+		}
+	case e.Op&(Remove|Rename) != 0 && strings.HasPrefix(w.path, e.Path+"/"):
+		return Event{Path: w.path, Op: Remove}, true
+	}
+	return Event{}, false
+}
+
+// watchers dispatches the events to the watches in the order they were added
+type watchers struct {
+	mu      sync.Mutex
+	watches []*watch
+}
+
+func (ws *watchers) add(path string, recursive bool, callback func(Event)) func() {
+	w := &watch{
+		path:      path,
+		recursive: recursive,
+		callback:  callback,
+	}
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+	ws.watches = append(ws.watches, w)
+	return func() {
+		ws.mu.Lock()
+		defer ws.mu.Unlock()
+		for i, added := range ws.watches {
+			if added == w {
+				ws.watches = append(ws.watches[:i:i], ws.watches[i+1:]...)
+				return
+			}
+		}
+	}
+}
+
+func (ws *watchers) notify(path string, op EventOp) {
+	ws.mu.Lock()
+	// the stopped watches are removed with a copy, so the slice is not changed while notifying
+	watches := ws.watches
+	ws.mu.Unlock()
+	for _, w := range watches {
+		if e, ok := w.match(Event{Path: path, Op: op}); ok {
+			w.callback(e)
+		}
+	}
+}
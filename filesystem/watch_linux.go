@@ -0,0 +1,291 @@
+//go:build linux
+
+package filesystem
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"unsafe"
+)
+
+const inotifyMask = syscall.IN_CREATE | syscall.IN_MODIFY | syscall.IN_DELETE | syscall.IN_MOVED_FROM |
+	syscall.IN_MOVED_TO | syscall.IN_DELETE_SELF | syscall.IN_MOVE_SELF | syscall.IN_ONLYDIR
+
+// inotify is the inotify instance shared by the watches of a SandboxFS, it is closed with the last watch
+type inotify struct {
+	// Fd would make the file blocking, so the descriptor is kept
+	fd   int
+	file *os.File
+	// mu guards the fields below and the descriptors of the watches
+	mu sync.Mutex
+	// the watched directories by watch descriptor, a directory added again gets the same descriptor
+	dirs map[int32]*inotifyDir
+	// the watches not stopped yet
+	watches int
+}
+
+// inotifyDir is a watched host directory and the watches which added it
+type inotifyDir struct {
+	path    string
+	watches []*inotifyWatch
+}
+
+// inotifyWatch watches a directory of the host, or the directory containing the watched file
+type inotifyWatch struct {
+	sandbox  *SandboxFS
+	notify   *inotify
+	callback func(Event)
+	// host path of the watched directory
+	dir string
+	// host path of the watched file, empty when a directory is watched
+	target    string
+	recursive bool
+	// the descriptors of the directories the watch added
+	wds     map[int32]bool
+	stopped bool
+}
+
+func newInotify() (*inotify, error) {
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return nil, err
+	}
+	// the non blocking file is managed by the runtime poller, so closing it unblocks the pending read
+	n := &inotify{
+		fd:   fd,
+		file: os.NewFile(uintptr(fd), "inotify"),
+		dirs: make(map[int32]*inotifyDir),
+	}
+	go n.read()
+	return n, nil
+}
+
+// Watch watches the changes with inotify. A missing path is watched through its parent directory,
+// so its creation is reported, the parent must exist. The watches share one inotify instance,
+// which is closed when all of them are stopped.
+func (s *SandboxFS) Watch(path string, recursive bool, callback func(Event)) (func(), error) {
+	hostPath, err := s.resolveOsPath(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := s.stat(hostPath)
+	if err != nil {
+		return nil, err
+	}
+	w := &inotifyWatch{
+		sandbox:   s,
+		callback:  callback,
+		dir:       hostPath,
+		recursive: recursive,
+		wds:       make(map[int32]bool),
+	}
+	if info == nil || !info.IsDir() {
+		w.dir = filepath.Dir(hostPath)
+		w.target = hostPath
+		w.recursive = false
+		if w.dir != s.root {
+			if _, err := s.resolveOsPath(s.toSandboxPath(w.dir)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	s.watchMu.Lock()
+	if s.notify == nil {
+		s.notify, err = newInotify()
+		if err != nil {
+			s.watchMu.Unlock()
+			return nil, fmt.Errorf("unable to watch \"%s\", %w", path, err)
+		}
+	}
+	w.notify = s.notify
+	w.notify.mu.Lock()
+	w.notify.watches++
+	w.notify.mu.Unlock()
+	s.watchMu.Unlock()
+	if err = w.addDir(w.dir); err != nil {
+		s.stopWatch(w)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, NewFileOrDirNotExists(path)
+		}
+		return nil, fmt.Errorf("unable to watch \"%s\", %w", path, err)
+	}
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.stopWatch(w)
+		})
+	}, nil
+}
+
+// stopWatch removes the directories only the watch was watching, and closes inotify after the last watch
+func (s *SandboxFS) stopWatch(w *inotifyWatch) {
+	s.watchMu.Lock()
+	defer s.watchMu.Unlock()
+	n := w.notify
+	n.mu.Lock()
+	w.stopped = true
+	for wd := range w.wds {
+		n.remove(wd, w)
+	}
+	n.watches--
+	last := n.watches == 0
+	n.mu.Unlock()
+	if last {
+		n.file.Close()
+		s.notify = nil
+	}
+}
+
+// remove removes the watch from the directory of the descriptor, it must be called with mu held
+func (n *inotify) remove(wd int32, w *inotifyWatch) {
+	delete(w.wds, wd)
+	d, ok := n.dirs[wd]
+	if !ok {
+		return
+	}
+	for i, other := range d.watches {
+		if other == w {
+			// copied, so the slices taken by dispatch are not changed
+			d.watches = append(d.watches[:i:i], d.watches[i+1:]...)
+			break
+		}
+	}
+	if len(d.watches) == 0 {
+		delete(n.dirs, wd)
+		_, _ = syscall.InotifyRmWatch(n.fd, uint32(wd))
+	}
+}
+
+// addDir watches the directory, and its sub directories if the watch is recursive
+func (w *inotifyWatch) addDir(dir string) error {
+	if !w.recursive {
+		return w.add(dir)
+	}
+	return filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir && errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := w.add(path); err != nil && (path == dir || !errors.Is(err, fs.ErrNotExist)) {
+			return err
+		}
+		return nil
+	})
+}
+
+func (w *inotifyWatch) add(dir string) error {
+	n := w.notify
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	// inotify is closed once all the watches are stopped, its descriptor may be reused
+	if w.stopped {
+		return nil
+	}
+	wd, err := syscall.InotifyAddWatch(n.fd, dir, inotifyMask)
+	if err != nil {
+		return &fs.PathError{Op: "inotify_add_watch", Path: dir, Err: err}
+	}
+	d, ok := n.dirs[int32(wd)]
+	if !ok {
+		d = &inotifyDir{}
+		n.dirs[int32(wd)] = d
+	}
+	// the descriptor follows a moved directory
+	d.path = dir
+	if !w.wds[int32(wd)] {
+		w.wds[int32(wd)] = true
+		d.watches = append(d.watches, w)
+	}
+	return nil
+}
+
+func (n *inotify) read() {
+	buf := make([]byte, 64*1024)
+	for {
+		nRead, err := n.file.Read(buf)
+		if err != nil {
+			return
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= nRead; {
+			raw := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			offset = nameStart + int(raw.Len)
+			if offset > nRead {
+				break
+			}
+			name := string(bytes.TrimRight(buf[nameStart:offset], "\x00"))
+			n.dispatch(raw.Wd, raw.Mask, name)
+		}
+	}
+}
+
+// dispatch passes the event to the watches of its directory
+func (n *inotify) dispatch(wd int32, mask uint32, name string) {
+	n.mu.Lock()
+	d, ok := n.dirs[wd]
+	if !ok {
+		n.mu.Unlock()
+		return
+	}
+	dir, watches := d.path, d.watches
+	if mask&syscall.IN_IGNORED != 0 {
+		delete(n.dirs, wd)
+		for _, w := range watches {
+			delete(w.wds, wd)
+		}
+	}
+	n.mu.Unlock()
+	for _, w := range watches {
+		w.handle(dir, mask, name)
+	}
+}
+
+func (w *inotifyWatch) handle(dir string, mask uint32, name string) {
+	path := dir
+	if name != "" {
+		path = filepath.Join(dir, name)
+	}
+	var op EventOp
+	switch {
+	case mask&(syscall.IN_CREATE|syscall.IN_MOVED_TO) != 0:
+		op = Create
+		if w.recursive && mask&syscall.IN_ISDIR != 0 {
+			_ = w.addDir(path)
+		}
+	case mask&syscall.IN_MODIFY != 0:
+		op = Write
+	case mask&(syscall.IN_DELETE|syscall.IN_DELETE_SELF) != 0:
+		op = Remove
+	case mask&(syscall.IN_MOVED_FROM|syscall.IN_MOVE_SELF) != 0:
+		op = Rename
+	default:
+		return
+	}
+	if mask&(syscall.IN_DELETE_SELF|syscall.IN_MOVE_SELF) != 0 {
+		// the removals of the sub directories are already reported by their parents
+		if dir != w.dir {
+			return
+		}
+		if w.target != "" {
+			path = w.target
+			op = Remove
+		}
+	}
+	if w.target != "" && path != w.target {
+		return
+	}
+	w.callback(Event{Path: w.sandbox.toSandboxPath(path), Op: op})
+}
+
+var _ Watcher = (*SandboxFS)(nil)
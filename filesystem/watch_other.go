@@ -0,0 +1,18 @@
+//go:build !linux
+
+package filesystem
+
+import (
+	"errors"
+	"fmt"
+)
+
+// inotify only exists on linux
+type inotify struct{}
+
+// Watch is only supported on linux, where it uses inotify.
+func (s *SandboxFS) Watch(path string, recursive bool, callback func(Event)) (func(), error) {
+	return nil, fmt.Errorf("unable to watch \"%s\", %w", path, errors.ErrUnsupported)
+}
+
+var _ Watcher = (*SandboxFS)(nil)
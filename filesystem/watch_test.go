@@ -0,0 +1,197 @@
+package filesystem_test
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func must(t *testing.T, err error) {
+	t.Helper()
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func watch(t *testing.T, w filesystem.Watcher, path string, recursive bool) <-chan filesystem.Event {
+	events := make(chan filesystem.Event, 100)
+	stop, err := w.Watch(path, recursive, func(e filesystem.Event) {
+		events <- e
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(stop)
+	return events
+}
+
+// waitEvent skips the other events until the expected one arrives
+func waitEvent(t *testing.T, events <-chan filesystem.Event, expected filesystem.Event) {
+	t.Helper()
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case e := <-events:
+			if e == expected {
+				return
+			}
+		case <-timeout:
+			t.Fatalf("timeout waiting for the event %s %s", expected.Op, expected.Path)
+		}
+	}
+}
+
+func noEvent(t *testing.T, events <-chan filesystem.Event) {
+	t.Helper()
+	select {
+	case e := <-events:
+		t.Errorf("unexpected event %s %s", e.Op, e.Path)
+	default:
+	}
+}
+
+func TestMemoryFSWatch(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	must(t, mfs.Mkdir("/src"))
+	must(t, mfs.WriteFile("/src/a.ts", "a"))
+	file := watch(t, mfs, "/src/a.ts", false)
+	dir := watch(t, mfs, "/src", false)
+	tree := watch(t, mfs, "/", true)
+	missing := watch(t, mfs, "/lib/b.ts", false)
+
+	next := func(events <-chan filesystem.Event, path string, op filesystem.EventOp) {
+		t.Helper()
+		select {
+		case e := <-events:
+			test.AssertEqual(t, filesystem.Event{Path: path, Op: op}, e, "")
+		default:
+			t.Errorf("missing the event %s %s", op, path)
+		}
+	}
+	must(t, mfs.WriteFile("/src/a.ts", "b"))
+	next(file, "/src/a.ts", filesystem.Write)
+	next(dir, "/src/a.ts", filesystem.Write)
+	next(tree, "/src/a.ts", filesystem.Write)
+
+	must(t, mfs.Mkdir("/src/lib/deep"))
+	next(dir, "/src/lib", filesystem.Create)
+	next(tree, "/src/lib", filesystem.Create)
+	next(tree, "/src/lib/deep", filesystem.Create)
+	noEvent(t, dir)
+
+	must(t, mfs.Copy("/src/a.ts", "/lib/b.ts"))
+	next(missing, "/lib/b.ts", filesystem.Create)
+	next(tree, "/lib", filesystem.Create)
+	next(tree, "/lib/b.ts", filesystem.Create)
+
+	must(t, mfs.Move("/src", "/out"))
+	next(file, "/src/a.ts", filesystem.Remove)
+	next(dir, "/src", filesystem.Rename)
+	next(tree, "/out", filesystem.Create)
+	next(tree, "/src", filesystem.Rename)
+	next(tree, "/out/a.ts", filesystem.Create)
+
+	must(t, mfs.Delete("/lib"))
+	next(missing, "/lib/b.ts", filesystem.Remove)
+	next(tree, "/lib", filesystem.Remove)
+	for _, events := range []<-chan filesystem.Event{file, dir, tree, missing} {
+		noEvent(t, events)
+	}
+}
+
+func TestSandboxFSWatch(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("watching is only supported on linux")
+	}
+	sfs := newSandboxFS()(t, map[string]string{
+		"/src/a.ts": "a",
+	}).(*filesystem.SandboxFS)
+	file := watch(t, sfs, "/src/a.ts", false)
+	missing := watch(t, sfs, "/src/b.ts", false)
+	tree := watch(t, sfs, "/", true)
+
+	must(t, sfs.WriteFile("/src/a.ts", "b"))
+	waitEvent(t, file, filesystem.Event{Path: "/src/a.ts", Op: filesystem.Write})
+	waitEvent(t, tree, filesystem.Event{Path: "/src/a.ts", Op: filesystem.Write})
+
+	must(t, sfs.Mkdir("/src/lib"))
+	waitEvent(t, tree, filesystem.Event{Path: "/src/lib", Op: filesystem.Create})
+	// the new directory is watched too
+	must(t, sfs.WriteFile("/src/lib/c.ts", "c"))
+	waitEvent(t, tree, filesystem.Event{Path: "/src/lib/c.ts", Op: filesystem.Create})
+
+	must(t, sfs.Move("/src/a.ts", "/src/b.ts"))
+	waitEvent(t, file, filesystem.Event{Path: "/src/a.ts", Op: filesystem.Rename})
+	waitEvent(t, missing, filesystem.Event{Path: "/src/b.ts", Op: filesystem.Create})
+	waitEvent(t, tree, filesystem.Event{Path: "/src/b.ts", Op: filesystem.Create})
+
+	must(t, sfs.Delete("/src"))
+	waitEvent(t, missing, filesystem.Event{Path: "/src/b.ts", Op: filesystem.Remove})
+	waitEvent(t, tree, filesystem.Event{Path: "/src", Op: filesystem.Remove})
+
+	_, err := sfs.Watch("/missing/a.ts", false, func(filesystem.Event) {})
+	test.AssertEqual(t, true, err != nil, "")
+	_, err = sfs.Watch("/../a.ts", false, func(filesystem.Event) {})
+	test.AssertEqual(t, true, err != nil, "")
+}
+
+// countInotify counts the inotify instances opened by the process
+func countInotify(t *testing.T) int {
+	entries, err := os.ReadDir("/proc/self/fd")
+	must(t, err)
+	count := 0
+	for _, entry := range entries {
+		if link, err := os.Readlink("/proc/self/fd/" + entry.Name()); err == nil && link == "anon_inode:inotify" {
+			count++
+		}
+	}
+	return count
+}
+
+func TestSandboxFSWatchShared(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("watching is only supported on linux")
+	}
+	files := make(map[string]string)
+	for i := 0; i < 200; i++ {
+		files[fmt.Sprintf("/src/%d.ts", i)] = "a"
+	}
+	sfs := newSandboxFS()(t, files).(*filesystem.SandboxFS)
+	before := countInotify(t)
+	// more watches than the default limit of inotify instances per user
+	var stops []func()
+	var events []chan filesystem.Event
+	for i := 0; i < 200; i++ {
+		ch := make(chan filesystem.Event, 10)
+		stop, err := sfs.Watch(fmt.Sprintf("/src/%d.ts", i), false, func(e filesystem.Event) {
+			ch <- e
+		})
+		must(t, err)
+		stops = append(stops, stop)
+		events = append(events, ch)
+	}
+	test.AssertEqual(t, before+1, countInotify(t), "")
+
+	must(t, sfs.WriteFile("/src/199.ts", "b"))
+	waitEvent(t, events[199], filesystem.Event{Path: "/src/199.ts", Op: filesystem.Write})
+	noEvent(t, events[0])
+
+	// the directory is still watched for the other watches
+	stops[199]()
+	must(t, sfs.WriteFile("/src/0.ts", "b"))
+	waitEvent(t, events[0], filesystem.Event{Path: "/src/0.ts", Op: filesystem.Write})
+
+	for _, stop := range stops {
+		stop()
+	}
+	test.AssertEqual(t, before, countInotify(t), "")
+	// a new inotify instance is opened after the last one is closed
+	tree := watch(t, sfs, "/", true)
+	must(t, sfs.WriteFile("/src/1.ts", "b"))
+	waitEvent(t, tree, filesystem.Event{Path: "/src/1.ts", Op: filesystem.Write})
+}
@@ -0,0 +1,241 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestFileSystemHostNotFound(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.WriteFile("/a.txt", "a"))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	res, err := ctx.RunScript(`
+		function describe(fn) {
+			try {
+				fn();
+				return 'no error';
+			} catch (e) {
+				return [e.name, e.code, e.path, e.message].join('|');
+			}
+		}
+		[
+			describe(() => host.readFileSync('/missing.txt')),
+			describe(() => host.readDirSync('/missing')),
+			describe(() => host.deleteSync('/missing.txt')),
+			host.fileExistsSync('/missing/a.txt'),
+			host.directoryExistsSync('/missing/a'),
+		].join('\n')
+	`, "not-found.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "FileNotFoundError|ENOENT|/missing.txt|File not found: /missing.txt\n"+
+		"DirectoryNotFoundError|ENOENT|/missing|Directory not found: /missing\n"+
+		"FileNotFoundError|ENOENT|/missing.txt|File not found: /missing.txt\n"+
+		"false\nfalse", res.String(), "")
+
+	promise, err := ctx.RunScript("host.readFile('/missing.txt')", "not-found.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	var ex *JSException
+	test.MustEqual(t, true, errors.As(err, &ex), "")
+	test.AssertEqual(t, "FileNotFoundError", ex.Name, "")
+	test.AssertEqual(t, "/missing.txt", ex.Path, "")
+	test.AssertEqual(t, true, errors.Is(err, fs.ErrNotExist), "")
+
+	promise, err = ctx.RunScript("host.fileExists('/missing/a.txt')", "not-found.js")
+	panicIfErr(err)
+	v, err := rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, false, v.Boolean(), "")
+}
+
+func TestFileSystemHostBytes(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.WriteFileBytes("/a.wasm", []byte{0, 0x80, 0xff, 'a'}))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	res, err := ctx.RunScript(`
+		const bytes = host.readFileBytesSync('/a.wasm');
+		host.writeFileBytesSync('/b.bin', new Uint8Array([1, 2, 255]).subarray(1));
+		host.writeFileBytesSync('/c.bin', new Uint16Array([0x0102]).buffer);
+		let failure;
+		try {
+			host.writeFileBytesSync('/d.bin', 'text');
+		} catch (e) {
+			failure = e.message;
+		}
+		[
+			bytes instanceof Uint8Array, Array.from(bytes).join(','),
+			host.readFileSync('/a.wasm', 'base64'), host.readFileSync('/a.wasm', 'latin1').charCodeAt(2),
+			failure,
+		].join('|')
+	`, "bytes.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "true|0,128,255,97|AID/YQ==|255|the arg 1 is not an ArrayBuffer or an ArrayBufferView", res.String(), "")
+	data, err := mfs.ReadFileBytes("/b.bin")
+	panicIfErr(err)
+	test.AssertEqual(t, "\x02\xff", string(data), "")
+	data, err = mfs.ReadFileBytes("/c.bin")
+	panicIfErr(err)
+	test.AssertEqual(t, "\x02\x01", string(data), "")
+
+	promise, err := ctx.RunScript(`
+		host.writeFileBytes('/e.bin', new Uint8Array([7, 0, 8]))
+			.then(() => host.readFileBytes('/e.bin'))
+			.then((b) => Array.from(b).join(','))
+	`, "bytes.js")
+	panicIfErr(err)
+	res, err = rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, "7,0,8", res.String(), "")
+}
+
+func TestFileSystemHostStat(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/src"))
+	panicIfErr(mfs.WriteFile("/src/a.ts", "export {};"))
+	stat, err := mfs.Stat("/src/a.ts")
+	panicIfErr(err)
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	res, err := ctx.RunScript(`
+		const file = host.statSync('/src/a.ts');
+		const dir = host.lstatSync('/src');
+		let failure;
+		try {
+			host.statSync('/missing.ts');
+		} catch (e) {
+			failure = e.name;
+		}
+		[
+			file.size, file.isFile, file.isDirectory, file.isSymlink, (file.mode & 0o170000).toString(8),
+			file.mtime instanceof Date, file.mtime.getTime() === Math.floor(file.mtimeMs),
+			dir.isDirectory, (dir.mode & 0o777).toString(8), failure,
+		].join('|')
+	`, "stat.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "10|true|false|false|100000|true|true|true|777|FileNotFoundError", res.String(), "")
+
+	promise, err := ctx.RunScript("host.stat('/src/a.ts').then((s) => s.mtime.getTime())", "stat.js")
+	panicIfErr(err)
+	res, err = rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, stat.ModTime().UnixMilli(), res.Integer(), "")
+}
+
+// run with -race, the async host methods call the file system from the loop workers
+func TestFileSystemHostConcurrentWrites(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/out"))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	promise, err := ctx.RunScript(`
+		const writes = [];
+		for (let i = 0; i < 32; i++) {
+			writes.push(host.writeFile('/out/' + i + '.txt', 'content ' + i));
+			host.fileExistsSync('/out/' + (i - 1) + '.txt');
+			host.readDirSync('/out');
+		}
+		Promise.all(writes).then(() => host.readDirSync('/out').length)
+	`, "concurrent.js")
+	panicIfErr(err)
+	res, err := rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	test.AssertEqual(t, int32(32), res.Int32(), "")
+	content, err := mfs.ReadFile("/out/31.txt", "utf-8")
+	panicIfErr(err)
+	test.AssertEqual(t, "content 31", content, "")
+}
+
+func TestFileSystemHostWatch(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/src"))
+	panicIfErr(mfs.WriteFile("/src/a.ts", "a"))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	_, err := ctx.RunScript(`
+		const events = [];
+		const fileWatcher = host.watchFile('/src/a.ts', (fileName, kind) => events.push('file ' + fileName + ' ' + kind));
+		const dirWatcher = host.watchDirectory('/src', (fileName) => events.push('dir ' + fileName), true);
+	`, "watch.js")
+	panicIfErr(err)
+	panicIfErr(mfs.WriteFile("/src/a.ts", "b"))
+	panicIfErr(mfs.Mkdir("/src/lib"))
+	// the events are delivered on the loop
+	res, err := ctx.RunScript("events.length", "watch.js")
+	panicIfErr(err)
+	test.AssertEqual(t, int64(0), res.Integer(), "")
+	panicIfErr(rt.Loop().Run(context.Background()))
+
+	_, err = ctx.RunScript("fileWatcher.close()", "watch.js")
+	panicIfErr(err)
+	panicIfErr(mfs.Delete("/src/a.ts"))
+	// the queued events are dropped once the watcher is closed
+	_, err = ctx.RunScript("dirWatcher.close(); dirWatcher.close()", "watch.js")
+	panicIfErr(err)
+	panicIfErr(mfs.WriteFile("/src/b.ts", "b"))
+	panicIfErr(rt.Loop().Run(context.Background()))
+	res, err = ctx.RunScript("events.join('\\n')", "watch.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "file /src/a.ts 1\ndir /src/a.ts\ndir /src/lib", res.String(), "")
+
+	res, err = ctx.RunScript(`
+		try {
+			host.watchFile('/a.ts', 1);
+		} catch (e) {
+			e.message;
+		}
+	`, "watch.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "the arg 1 is not a function", res.String(), "")
+}
+
+func TestFileSystemHostCloseWatchers(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	rt := newTestRuntime(t, nil)
+	fsh := NewV8FileSystem(mfs, rt.Utils())
+	host, err := fsh.CreateInstance()
+	panicIfErr(err)
+	ctx := rt.Context()
+	panicIfErr(ctx.Global().Set("host", host))
+	_, err = ctx.RunScript(`
+		const events = [];
+		const watcher = host.watchDirectory('/', (fileName) => events.push(fileName), true);
+	`, "watch.js")
+	panicIfErr(err)
+	panicIfErr(mfs.WriteFile("/a.ts", "a"))
+	test.AssertEqual(t, false, rt.Loop().Idle(), "")
+
+	// the queued events are dropped too
+	fsh.Close()
+	fsh.Close()
+	panicIfErr(mfs.WriteFile("/b.ts", "b"))
+	panicIfErr(rt.Loop().Run(context.Background()))
+	res, err := ctx.RunScript("watcher.close(); events.length", "watch.js")
+	panicIfErr(err)
+	test.AssertEqual(t, int64(0), res.Integer(), "")
+}
+
+func TestFileSystemHostWatchUnsupported(t *testing.T) {
+	rt := newTestRuntime(t, filesystem.NewIOFS(fstest.MapFS{}))
+	res, err := rt.Context().RunScript(`
+		try {
+			host.watchDirectory('/', () => {});
+		} catch (e) {
+			e.code;
+		}
+	`, "watch.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "ENOSYS", res.String(), "")
+}
@@ -0,0 +1,41 @@
+// Produces the bundle embedded by the go package (see bundle.go):
+//   dist/bootstrap.js   - src/index.ts transpiled to a plain script
+//   dist/typescript.js  - the typescript compiler itself
+//   dist/lib/*.d.ts     - the default lib files served to the compiler host
+// Only dist/bootstrap.js is committed, the other files must be generated with
+// `pnpm install && pnpm run build` before the go package is built. Without them
+// NewCompiler returns ErrBundleNotBuilt unless a bundle is given with WithBundle.
+// The CI builds them and runs the go tests against the real compiler.
+import fs from 'node:fs';
+import path from 'node:path';
+import { createRequire } from 'node:module';
+import { fileURLToPath } from 'node:url';
+
+const require = createRequire(import.meta.url);
+const ts = require('typescript');
+
+const root = path.dirname(path.dirname(fileURLToPath(import.meta.url)));
+const dist = path.join(root, 'dist');
+const tsLib = path.dirname(require.resolve('typescript'));
+
+fs.rmSync(dist, { recursive: true, force: true });
+fs.mkdirSync(path.join(dist, 'lib'), { recursive: true });
+
+const source = fs.readFileSync(path.join(root, 'src', 'index.ts'), 'utf-8');
+const output = ts.transpileModule(source, {
+    fileName: 'index.ts',
+    compilerOptions: {
+        target: ts.ScriptTarget.ES2020,
+        module: ts.ModuleKind.None,
+        removeComments: false,
+    },
+});
+fs.writeFileSync(path.join(dist, 'bootstrap.js'), output.outputText);
+
+fs.copyFileSync(path.join(tsLib, 'typescript.js'), path.join(dist, 'typescript.js'));
+for (const name of fs.readdirSync(tsLib)) {
+    if (/^lib(\..+)?\.d\.ts$/.test(name)) {
+        fs.copyFileSync(path.join(tsLib, name), path.join(dist, 'lib', name));
+    }
+}
+fs.writeFileSync(path.join(dist, 'VERSION'), ts.version);
@@ -1,3 +1,320 @@
-import { 
-    FileSystemHost
- } from '@ts-morph/bootstrap'
\ No newline at end of file
+// Bootstrap script evaluated in the v8 context right after typescript.js.
+// It has no imports on purpose: the context has no module loader, so this file
+// is transpiled as a plain script and exposes everything through the `v8tsgo`
+// global. `ts` is the global defined by typescript.js, the file system host is
+// the object created by `V8FileSystemHost.CreateInstance` and the bundle object
+// is installed by the go side to serve the embedded lib files.
+
+declare const ts: typeof import('typescript');
+
+interface RuntimeDirEntry {
+    name: string;
+    isFile: boolean;
+    isDirectory: boolean;
+    isSymlink: boolean;
+}
+
+interface GoFileSystemHost {
+    isCaseSensitive(): boolean;
+    readDirSync(dirPath: string): RuntimeDirEntry[];
+    readFileSync(filePath: string, encoding?: string): string;
+    writeFileSync(filePath: string, fileText: string): void;
+    mkdirSync(dirPath: string): void;
+    fileExistsSync(filePath: string): boolean;
+    directoryExistsSync(dirPath: string): boolean;
+    realpathSync(path: string): string;
+    getCurrentDirectory(): string;
+}
+
+interface GoBundle {
+    libDir: string;
+    readLib(fileName: string): string | undefined;
+}
+
+interface DiagnosticMessageChain {
+    messageText: string;
+    category: number;
+    code: number;
+    next: DiagnosticMessageChain[];
+}
+
+interface Diagnostic {
+    file: string;
+    start: number;
+    length: number;
+    line: number;
+    column: number;
+    code: number;
+    category: number;
+    message: string;
+    messageChain?: DiagnosticMessageChain;
+    relatedInformation: Diagnostic[];
+}
+
+var v8tsgo = (function () {
+    function joinPath(dir: string, name: string): string {
+        return dir.endsWith('/') ? dir + name : dir + '/' + name;
+    }
+
+    function dirName(path: string): string {
+        const i = path.lastIndexOf('/');
+        if (i <= 0) {
+            return '/';
+        }
+        return path.substring(0, i);
+    }
+
+    function isLibPath(bundle: GoBundle, path: string): boolean {
+        return path.startsWith(bundle.libDir + '/');
+    }
+
+    function createCompilerHost(host: GoFileSystemHost, bundle: GoBundle, options: import('typescript').CompilerOptions): import('typescript').CompilerHost {
+        const caseSensitive = host.isCaseSensitive();
+        function readFile(fileName: string): string | undefined {
+            if (isLibPath(bundle, fileName)) {
+                return bundle.readLib(fileName.substring(bundle.libDir.length + 1));
+            }
+            try {
+                return host.readFileSync(fileName, 'utf-8');
+            } catch (e) {
+                return undefined;
+            }
+        }
+        function fileExists(fileName: string): boolean {
+            if (isLibPath(bundle, fileName)) {
+                return bundle.readLib(fileName.substring(bundle.libDir.length + 1)) !== undefined;
+            }
+            try {
+                return host.fileExistsSync(fileName);
+            } catch (e) {
+                return false;
+            }
+        }
+        function directoryExists(dirName: string): boolean {
+            if (dirName === bundle.libDir) {
+                return true;
+            }
+            try {
+                return host.directoryExistsSync(dirName);
+            } catch (e) {
+                return false;
+            }
+        }
+        function ensureDirectory(dirPath: string): void {
+            if (!directoryExists(dirPath)) {
+                host.mkdirSync(dirPath);
+            }
+        }
+        return {
+            getSourceFile(fileName, languageVersionOrOptions, onError) {
+                const text = readFile(fileName);
+                if (text === undefined) {
+                    if (onError) {
+                        onError('File not found: ' + fileName);
+                    }
+                    return undefined;
+                }
+                return ts.createSourceFile(fileName, text, languageVersionOrOptions);
+            },
+            getDefaultLibFileName(options) {
+                return joinPath(bundle.libDir, ts.getDefaultLibFileName(options));
+            },
+            getDefaultLibLocation() {
+                return bundle.libDir;
+            },
+            writeFile(fileName, text) {
+                ensureDirectory(dirName(fileName));
+                host.writeFileSync(fileName, text);
+            },
+            getCurrentDirectory() {
+                return host.getCurrentDirectory();
+            },
+            getDirectories(path) {
+                try {
+                    return host.readDirSync(path).filter((e) => e.isDirectory).map((e) => e.name);
+                } catch (e) {
+                    return [];
+                }
+            },
+            getCanonicalFileName(fileName) {
+                return caseSensitive ? fileName : fileName.toLowerCase();
+            },
+            useCaseSensitiveFileNames() {
+                return caseSensitive;
+            },
+            getNewLine() {
+                return options.newLine === ts.NewLineKind.CarriageReturnLineFeed ? '\r\n' : '\n';
+            },
+            fileExists,
+            readFile,
+            directoryExists,
+            realpath(path) {
+                try {
+                    return host.realpathSync(path);
+                } catch (e) {
+                    return path;
+                }
+            },
+        };
+    }
+
+    function toMessageChain(chain: import('typescript').DiagnosticMessageChain): DiagnosticMessageChain {
+        return {
+            messageText: chain.messageText,
+            category: chain.category,
+            code: chain.code,
+            next: (chain.next ?? []).map(toMessageChain),
+        };
+    }
+
+    function toDiagnostic(d: import('typescript').Diagnostic | import('typescript').DiagnosticRelatedInformation): Diagnostic {
+        let line = 0;
+        let column = 0;
+        if (d.file && d.start !== undefined) {
+            const pos = ts.getLineAndCharacterOfPosition(d.file, d.start);
+            line = pos.line + 1;
+            column = pos.character + 1;
+        }
+        const related = (d as import('typescript').Diagnostic).relatedInformation ?? [];
+        return {
+            file: d.file ? d.file.fileName : '',
+            start: d.start ?? 0,
+            length: d.length ?? 0,
+            line,
+            column,
+            code: d.code,
+            category: d.category,
+            message: ts.flattenDiagnosticMessageText(d.messageText, '\n'),
+            messageChain: typeof d.messageText === 'string' ? undefined : toMessageChain(d.messageText),
+            relatedInformation: related.map(toDiagnostic),
+        };
+    }
+
+    function toDiagnostics(diagnostics: readonly import('typescript').Diagnostic[]): Diagnostic[] {
+        return diagnostics.map(toDiagnostic);
+    }
+
+    function transpile(source: string, transpileOptions: import('typescript').TranspileOptions) {
+        const output = ts.transpileModule(source, Object.assign({}, transpileOptions, {
+            reportDiagnostics: true,
+        }));
+        return {
+            outputText: output.outputText,
+            sourceMapText: output.sourceMapText ?? '',
+            diagnostics: toDiagnostics(output.diagnostics ?? []),
+        };
+    }
+
+    function createProgram(host: GoFileSystemHost, bundle: GoBundle, rootNames: string[], options: import('typescript').CompilerOptions) {
+        const compilerHost = createCompilerHost(host, bundle, options);
+        return { compilerHost, program: ts.createProgram({ rootNames, options, host: compilerHost }) };
+    }
+
+    function typeCheck(host: GoFileSystemHost, bundle: GoBundle, rootNames: string[], options: import('typescript').CompilerOptions) {
+        const { program } = createProgram(host, bundle, rootNames, options);
+        return toDiagnostics(ts.getPreEmitDiagnostics(program));
+    }
+
+    function emit(host: GoFileSystemHost, bundle: GoBundle, rootNames: string[], options: import('typescript').CompilerOptions) {
+        const { compilerHost, program } = createProgram(host, bundle, rootNames, options);
+        const emittedFiles: string[] = [];
+        const result = program.emit(undefined, (fileName, text, writeBOM, onError, sourceFiles, data) => {
+            compilerHost.writeFile(fileName, text, writeBOM, onError, sourceFiles, data);
+            emittedFiles.push(fileName);
+        });
+        return {
+            emitSkipped: result.emitSkipped,
+            emittedFiles,
+            diagnostics: toDiagnostics(ts.getPreEmitDiagnostics(program).concat(result.diagnostics)),
+        };
+    }
+
+    // the hash typescript uses when the host has no createHash, the versions stored in .tsbuildinfo are made of it
+    function generateDjb2Hash(data: string): string {
+        let acc = 5381;
+        for (let i = 0; i < data.length; i++) {
+            acc = ((acc << 5) + acc) + data.charCodeAt(i);
+        }
+        return acc.toString();
+    }
+
+    // createBuilder keeps a builder program alive between the builds. The source files are cached until
+    // their paths are passed to build as changed, and the first build starts from the state stored in the
+    // .tsbuildinfo file, so only the affected files are checked and emitted again. The imports of the
+    // referenced projects are resolved to their declaration outputs, as tsc -b does.
+    function createBuilder(host: GoFileSystemHost, bundle: GoBundle, rootNames: string[], options: import('typescript').CompilerOptions, projectReferences: readonly import('typescript').ProjectReference[]) {
+        options = Object.assign({}, options);
+        if (options.incremental === undefined && !options.composite) {
+            options.incremental = true;
+        }
+        const compilerHost = createCompilerHost(host, bundle, options);
+        compilerHost.createHash = generateDjb2Hash;
+        const sourceFiles = new Map<string, import('typescript').SourceFile>();
+        const getSourceFile = compilerHost.getSourceFile;
+        compilerHost.getSourceFile = (fileName, languageVersionOrOptions, onError, shouldCreateNewSourceFile) => {
+            const key = compilerHost.getCanonicalFileName(fileName);
+            let sourceFile = shouldCreateNewSourceFile ? undefined : sourceFiles.get(key);
+            if (!sourceFile) {
+                sourceFile = getSourceFile(fileName, languageVersionOrOptions, onError);
+                if (!sourceFile) {
+                    return undefined;
+                }
+                (sourceFile as { version?: string }).version = generateDjb2Hash(sourceFile.text);
+                sourceFiles.set(key, sourceFile);
+            }
+            return sourceFile;
+        };
+        let builderProgram: import('typescript').EmitAndSemanticDiagnosticsBuilderProgram | undefined;
+
+        function build(changedPaths: string[]) {
+            changedPaths.forEach((path) => sourceFiles.delete(compilerHost.getCanonicalFileName(path)));
+            const oldProgram = builderProgram ?? ts.readBuilderProgram(options, compilerHost);
+            builderProgram = ts.createEmitAndSemanticDiagnosticsBuilderProgram(rootNames, options, compilerHost, oldProgram, undefined, projectReferences);
+            const affectedFiles: string[] = [];
+            for (;;) {
+                const next = builderProgram.getSemanticDiagnosticsOfNextAffectedFile();
+                if (!next) {
+                    break;
+                }
+                if ('fileName' in next.affected) {
+                    affectedFiles.push(next.affected.fileName);
+                }
+            }
+            const emittedFiles: string[] = [];
+            const result = builderProgram.emit(undefined, (fileName, text, writeBOM, onError, sourceFiles, data) => {
+                compilerHost.writeFile(fileName, text, writeBOM, onError, sourceFiles, data);
+                emittedFiles.push(fileName);
+            });
+            // the program diagnostics would check all the files again, the builder ones are cached
+            const diagnostics = [
+                ...builderProgram.getConfigFileParsingDiagnostics(),
+                ...builderProgram.getOptionsDiagnostics(),
+                ...builderProgram.getGlobalDiagnostics(),
+                ...builderProgram.getSyntacticDiagnostics(),
+                ...builderProgram.getSemanticDiagnostics(),
+                ...result.diagnostics,
+            ];
+            return {
+                affectedFiles,
+                emitSkipped: result.emitSkipped,
+                emittedFiles,
+                diagnostics: toDiagnostics(diagnostics),
+            };
+        }
+
+        function setRootNames(names: string[]) {
+            rootNames = names;
+        }
+
+        return { build, setRootNames };
+    }
+
+    return {
+        version: ts.version,
+        createCompilerHost,
+        transpile,
+        typeCheck,
+        emit,
+        createBuilder,
+    };
+})();
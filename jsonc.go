@@ -0,0 +1,103 @@
+package v8tsgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+)
+
+// stripJSONC blanks the comments and the trailing commas of the json with comments tsconfig files are written in.
+// They are replaced by spaces, so the offsets of the json errors still locate the source.
+func stripJSONC(data []byte) []byte {
+	out := bytes.Clone(data)
+	blank := func(from, to int) {
+		for i := from; i < to; i++ {
+			if out[i] != '\n' && out[i] != '\r' {
+				out[i] = ' '
+			}
+		}
+	}
+	// the comments first, the commas are looked after them
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			end := bytes.IndexByte(out[i:], '\n')
+			if end < 0 {
+				end = len(out) - i
+			}
+			blank(i, i+end)
+			i += end
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				end = len(out) - i
+			} else {
+				end += 4
+			}
+			blank(i, i+end)
+			i += end - 1
+		}
+	}
+	for i := 0; i < len(out); i++ {
+		switch out[i] {
+		case '"':
+			for i++; i < len(out) && out[i] != '"'; i++ {
+				if out[i] == '\\' {
+					i++
+				}
+			}
+		case ',':
+			j := i + 1
+			for j < len(out) && (out[j] == ' ' || out[j] == '\t' || out[j] == '\n' || out[j] == '\r') {
+				j++
+			}
+			if j < len(out) && (out[j] == '}' || out[j] == ']') {
+				out[i] = ' '
+			}
+		}
+	}
+	return out
+}
+
+// lineColumn returns the 1-based line and column of the offset
+func lineColumn(data []byte, offset int) (int, int) {
+	if offset > len(data) {
+		offset = len(data)
+	}
+	before := data[:offset]
+	line := bytes.Count(before, []byte("\n")) + 1
+	return line, offset - bytes.LastIndexByte(before, '\n')
+}
+
+// parseJSONC parses the json with comments, the syntax errors are returned as a diagnostic.
+func parseJSONC(fileName string, data []byte, out any) *Diagnostic {
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+	err := json.Unmarshal(stripJSONC(data), out)
+	if err == nil {
+		return nil
+	}
+	d := &Diagnostic{
+		File:     fileName,
+		Code:     diagnosticJSONSyntax,
+		Category: DiagnosticCategoryError,
+		Message:  err.Error(),
+	}
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &syntaxErr) {
+		d.Start = int(syntaxErr.Offset)
+	} else if errors.As(err, &typeErr) {
+		d.Code = diagnosticRootNotObject
+		d.Message = fmt.Sprintf("The root value of a '%s' file must be an object.", path.Base(fileName))
+	}
+	d.Line, d.Column = lineColumn(data, d.Start)
+	return d
+}
@@ -0,0 +1,147 @@
+package v8tsgo
+
+import (
+	"context"
+	"sync"
+
+	v8 "rogchap.com/v8go"
+)
+
+// Loop is the event loop of a v8 context. v8 values must only be touched by the goroutine
+// driving the context, so the work started from js runs on worker goroutines and queues its
+// completion on the loop, which runs it when the owner of the context calls RunOnce or Run.
+type Loop struct {
+	ctx *v8.Context
+
+	mu sync.Mutex
+	// completions waiting to run on the owner goroutine
+	tasks []func()
+	// work started by Go and not completed yet
+	pending int
+	// signaled when a task is queued
+	wake chan struct{}
+	// set by Close, the tasks queued after are dropped
+	closed bool
+	// cancelled when the loop is closed, see Context
+	done   context.Context
+	cancel context.CancelFunc
+}
+
+func newLoop(ctx *v8.Context) *Loop {
+	done, cancel := context.WithCancel(context.Background())
+	return &Loop{
+		ctx:    ctx,
+		wake:   make(chan struct{}, 1),
+		done:   done,
+		cancel: cancel,
+	}
+}
+
+// Context returns the context the work started by Go should watch, it is cancelled when the loop is closed.
+// A caller of Wait, Run or Runtime.Await giving up doesn't cancel it, the work may be awaited by others.
+func (l *Loop) Context() context.Context {
+	return l.done
+}
+
+// Close cancels the context of the work and drops the queued tasks, the tasks queued after are dropped too,
+// since the v8 context may be gone. It is safe to call from any goroutine and more than once.
+func (l *Loop) Close() {
+	l.mu.Lock()
+	l.closed = true
+	l.tasks = nil
+	l.mu.Unlock()
+	l.cancel()
+}
+
+// Enqueue queues the task to run on the loop, it is safe to call from any goroutine.
+// The task is dropped if the loop is closed.
+func (l *Loop) Enqueue(task func()) {
+	l.mu.Lock()
+	if !l.closed {
+		l.tasks = append(l.tasks, task)
+		l.signal()
+	}
+	l.mu.Unlock()
+}
+
+// signal must be called with mu held, so RunOnce never leaves a stale wake up behind.
+func (l *Loop) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Go runs work on a new goroutine, the function it returns is queued on the loop unless the loop is closed.
+// work must not touch any v8 value, the completion may.
+func (l *Loop) Go(work func() func()) {
+	l.mu.Lock()
+	l.pending++
+	l.mu.Unlock()
+	go func() {
+		complete := work()
+		l.mu.Lock()
+		l.pending--
+		if complete != nil && !l.closed {
+			l.tasks = append(l.tasks, complete)
+		}
+		l.signal()
+		l.mu.Unlock()
+	}()
+}
+
+// Idle reports whether no task is queued and no work started by Go is running.
+func (l *Loop) Idle() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.pending == 0 && len(l.tasks) == 0
+}
+
+// RunOnce runs the queued tasks followed by a microtask checkpoint,
+// it returns false if there was nothing to run. It must be called by the owner of the context.
+func (l *Loop) RunOnce() bool {
+	l.mu.Lock()
+	tasks := l.tasks
+	l.tasks = nil
+	// the queued tasks are taken, so the pending wake up is consumed too
+	select {
+	case <-l.wake:
+	default:
+	}
+	l.mu.Unlock()
+	for _, task := range tasks {
+		task()
+	}
+	l.ctx.PerformMicrotaskCheckpoint()
+	return len(tasks) > 0
+}
+
+// Wait blocks until a task is queued or ctx is done.
+func (l *Loop) Wait(ctx context.Context) error {
+	l.mu.Lock()
+	ready := len(l.tasks) > 0
+	l.mu.Unlock()
+	if ready {
+		return nil
+	}
+	select {
+	case <-l.wake:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Run runs the loop until it is idle or ctx is done. It must be called by the owner of the context.
+func (l *Loop) Run(ctx context.Context) error {
+	for {
+		l.RunOnce()
+		if l.Idle() {
+			return nil
+		}
+		err := l.Wait(ctx)
+		if err != nil {
+			return err
+		}
+	}
+}
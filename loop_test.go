@@ -0,0 +1,104 @@
+package v8tsgo
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+	"rogchap.com/v8go"
+)
+
+func newTestRuntime(t *testing.T, fs filesystem.FileSystem) *Runtime {
+	t.Helper()
+	ctx := v8go.NewContext()
+	t.Cleanup(func() {
+		closeContext(ctx)
+	})
+	rt, err := NewRuntime(ctx)
+	panicIfErr(err)
+	if fs != nil {
+		host, err := NewV8FileSystem(fs, rt.Utils()).CreateInstance()
+		panicIfErr(err)
+		panicIfErr(ctx.Global().Set("host", host))
+	}
+	return rt
+}
+
+func TestLoopSettlesHostPromises(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.WriteFile("/a.txt", "hello"))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+	_, err := ctx.RunScript(`
+		var result = [];
+		host.readFile('/a.txt').then((text) => result.push(text));
+		host.readFile('/missing.txt').catch((e) => result.push('missing'));
+	`, "test.js")
+	panicIfErr(err)
+
+	runCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	panicIfErr(rt.Loop().Run(runCtx))
+	test.AssertEqual(t, true, rt.Loop().Idle(), "")
+	v, err := ctx.RunScript("result.sort().join(',')", "check.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "hello,missing", v.String(), "")
+}
+
+func TestLoopEnqueue(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	loop := rt.Loop()
+	test.AssertEqual(t, true, loop.Idle(), "")
+	test.AssertEqual(t, false, loop.RunOnce(), "")
+
+	release := make(chan struct{})
+	ran := false
+	loop.Go(func() func() {
+		<-release
+		return func() {
+			ran = true
+		}
+	})
+	test.AssertEqual(t, false, loop.Idle(), "")
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	test.AssertEqual(t, context.DeadlineExceeded, loop.Run(ctx), "")
+	close(release)
+	panicIfErr(loop.Run(context.Background()))
+	test.AssertEqual(t, true, ran, "")
+
+	go loop.Enqueue(func() {
+		ran = false
+	})
+	panicIfErr(loop.Wait(context.Background()))
+	test.AssertEqual(t, true, loop.RunOnce(), "")
+	test.AssertEqual(t, false, ran, "")
+}
+
+func TestLoopClose(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	loop := rt.Loop()
+	ran := false
+	loop.Enqueue(func() {
+		ran = true
+	})
+	loop.Close()
+	loop.Close()
+	loop.Enqueue(func() {
+		ran = true
+	})
+	done := make(chan struct{})
+	loop.Go(func() func() {
+		defer close(done)
+		<-loop.Context().Done()
+		return func() {
+			ran = true
+		}
+	})
+	<-done
+	panicIfErr(loop.Run(context.Background()))
+	test.AssertEqual(t, false, ran, "")
+	test.AssertEqual(t, true, loop.Idle(), "")
+}
@@ -0,0 +1,256 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+)
+
+var ErrPoolClosed = errors.New("the compiler pool is closed")
+
+type PoolOptions struct {
+	// Size is the number of isolates kept by the pool, defaults to 1.
+	Size int
+	// MaxUses recycles an isolate after it has been acquired this many times, 0 means no limit.
+	MaxUses int
+	// MaxHeapSize recycles an isolate when its used heap size exceeds this many bytes on release, 0 means no limit.
+	MaxHeapSize uint64
+	// CompilerOptions are passed to NewCompiler when an isolate is created.
+	CompilerOptions []CompilerOption
+}
+
+type PoolStats struct {
+	// Size is the maximum number of isolates.
+	Size int
+	// Open is the number of isolates alive or being created.
+	Open  int
+	Idle  int
+	InUse int
+	// Created is the total number of isolates created.
+	Created int64
+	// Recycled is the total number of isolates closed because of MaxUses or MaxHeapSize.
+	Recycled int64
+	Acquired int64
+	// WaitCount is the total number of acquires that had to wait for an isolate, including the canceled ones.
+	WaitCount    int64
+	WaitDuration time.Duration
+}
+
+type pooledCompiler struct {
+	compiler *Compiler
+	uses     int
+}
+
+// Pool keeps warmed compilers sharing the same file system,
+// each one owns its isolate so acquired compilers can be used concurrently.
+// The file system must be safe for concurrent use if the pool size is greater than 1,
+// MemoryFS and SandboxFS are, IOFS is as long as its fs.FS is.
+type Pool struct {
+	fs   filesystem.FileSystem
+	opts PoolOptions
+
+	mu     sync.Mutex
+	idle   chan *pooledCompiler
+	inUse  map[*Compiler]*pooledCompiler
+	open   int
+	closed bool
+	// closed and replaced every time a slot is given back, so the waiters can retry to create a compiler
+	freed chan struct{}
+	stats PoolStats
+}
+
+// NewPool creates the pool and warms all its isolates.
+func NewPool(fileSystem filesystem.FileSystem, opts PoolOptions) (*Pool, error) {
+	if opts.Size <= 0 {
+		opts.Size = 1
+	}
+	p := &Pool{
+		fs:    fileSystem,
+		opts:  opts,
+		idle:  make(chan *pooledCompiler, opts.Size),
+		inUse: make(map[*Compiler]*pooledCompiler),
+		freed: make(chan struct{}),
+	}
+	for i := 0; i < opts.Size; i++ {
+		p.open++
+		pc, err := p.create()
+		if err != nil {
+			p.Close()
+			return nil, err
+		}
+		p.idle <- pc
+	}
+	return p, nil
+}
+
+func (p *Pool) create() (*pooledCompiler, error) {
+	c, err := NewCompiler(p.fs, p.opts.CompilerOptions...)
+	if err != nil {
+		p.mu.Lock()
+		p.releaseSlot()
+		p.mu.Unlock()
+		return nil, err
+	}
+	p.mu.Lock()
+	p.stats.Created++
+	p.mu.Unlock()
+	return &pooledCompiler{compiler: c}, nil
+}
+
+// releaseSlot must be called with p.mu held.
+func (p *Pool) releaseSlot() {
+	p.open--
+	close(p.freed)
+	p.freed = make(chan struct{})
+}
+
+func (p *Pool) take(pc *pooledCompiler) *Compiler {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	pc.uses++
+	p.inUse[pc.compiler] = pc
+	p.stats.Acquired++
+	return pc.compiler
+}
+
+// Acquire returns an idle compiler, it blocks until one is released or ctx is done.
+// The compiler must be given back with Release.
+func (p *Pool) Acquire(ctx context.Context) (*Compiler, error) {
+	var waitStart time.Time
+	for {
+		select {
+		case pc, ok := <-p.idle:
+			if !ok {
+				return nil, ErrPoolClosed
+			}
+			p.recordWait(waitStart)
+			return p.take(pc), nil
+		default:
+		}
+		p.mu.Lock()
+		if p.closed {
+			p.mu.Unlock()
+			return nil, ErrPoolClosed
+		}
+		if p.open < p.opts.Size {
+			p.open++
+			p.mu.Unlock()
+			pc, err := p.create()
+			if err != nil {
+				return nil, err
+			}
+			p.recordWait(waitStart)
+			return p.take(pc), nil
+		}
+		freed := p.freed
+		if waitStart.IsZero() {
+			waitStart = time.Now()
+			p.stats.WaitCount++
+		}
+		p.mu.Unlock()
+		select {
+		case pc, ok := <-p.idle:
+			if !ok {
+				return nil, ErrPoolClosed
+			}
+			p.recordWait(waitStart)
+			return p.take(pc), nil
+		case <-freed:
+		case <-ctx.Done():
+			p.recordWait(waitStart)
+			return nil, ctx.Err()
+		}
+	}
+}
+
+func (p *Pool) recordWait(waitStart time.Time) {
+	if waitStart.IsZero() {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.stats.WaitDuration += time.Since(waitStart)
+}
+
+func (p *Pool) shouldRecycle(pc *pooledCompiler) bool {
+	if p.opts.MaxUses > 0 && pc.uses >= p.opts.MaxUses {
+		return true
+	}
+	if p.opts.MaxHeapSize > 0 {
+		heap := pc.compiler.ctx.Isolate().GetHeapStatistics()
+		if heap.UsedHeapSize > p.opts.MaxHeapSize {
+			return true
+		}
+	}
+	return false
+}
+
+// Release gives back a compiler returned by Acquire, it must not be used after this call.
+func (p *Pool) Release(c *Compiler) {
+	p.mu.Lock()
+	pc, ok := p.inUse[c]
+	if !ok {
+		p.mu.Unlock()
+		panic("v8tsgo: release of a compiler not acquired from the pool")
+	}
+	delete(p.inUse, c)
+	if p.closed {
+		p.releaseSlot()
+		p.mu.Unlock()
+		c.Close()
+		return
+	}
+	recycle := p.shouldRecycle(pc)
+	if !recycle {
+		p.idle <- pc
+		p.mu.Unlock()
+		return
+	}
+	p.stats.Recycled++
+	p.mu.Unlock()
+	c.Close()
+	// the slot stays reserved while the replacement is warming up
+	go func() {
+		pc, err := p.create()
+		if err != nil {
+			return
+		}
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if p.closed {
+			p.releaseSlot()
+			pc.compiler.Close()
+			return
+		}
+		p.idle <- pc
+	}()
+}
+
+func (p *Pool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	stats := p.stats
+	stats.Size = p.opts.Size
+	stats.Open = p.open
+	stats.Idle = len(p.idle)
+	stats.InUse = len(p.inUse)
+	return stats
+}
+
+// Close closes the idle compilers, the compilers in use are closed when they are released.
+func (p *Pool) Close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return
+	}
+	p.closed = true
+	close(p.idle)
+	for pc := range p.idle {
+		pc.compiler.Close()
+		p.releaseSlot()
+	}
+}
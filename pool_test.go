@@ -0,0 +1,149 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func newTestPool(t *testing.T, opts PoolOptions) *Pool {
+	t.Helper()
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/src"))
+	panicIfErr(mfs.WriteFile("/src/a.ts", "// @error broken"))
+	opts.CompilerOptions = append(opts.CompilerOptions, WithBundle(newTestBundle(t)))
+	p, err := NewPool(mfs, opts)
+	panicIfErr(err)
+	t.Cleanup(p.Close)
+	return p
+}
+
+func TestPoolAcquireRelease(t *testing.T) {
+	p := newTestPool(t, PoolOptions{Size: 2})
+	stats := p.Stats()
+	test.AssertEqual(t, 2, stats.Size, "")
+	test.AssertEqual(t, 2, stats.Idle, "")
+	test.AssertEqual(t, int64(2), stats.Created, "")
+
+	c1, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	c2, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	test.AssertEqual(t, true, c1 != c2, "")
+	test.AssertEqual(t, 2, p.Stats().InUse, "")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err = p.Acquire(ctx)
+	test.AssertEqual(t, true, errors.Is(err, context.DeadlineExceeded), "")
+
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		p.Release(c1)
+	}()
+	c3, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	test.AssertEqual(t, c1, c3, "")
+	stats = p.Stats()
+	test.AssertEqual(t, int64(3), stats.Acquired, "")
+	test.AssertEqual(t, int64(2), stats.WaitCount, "")
+	p.Release(c2)
+	p.Release(c3)
+	test.AssertEqual(t, 2, p.Stats().Idle, "")
+
+	p.Close()
+	_, err = p.Acquire(context.Background())
+	test.AssertEqual(t, ErrPoolClosed, err, "")
+}
+
+func TestPoolRecycle(t *testing.T) {
+	p := newTestPool(t, PoolOptions{Size: 1, MaxUses: 2})
+	c1, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	p.Release(c1)
+	c2, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	test.AssertEqual(t, c1, c2, "")
+	p.Release(c2)
+	c3, err := p.Acquire(context.Background())
+	panicIfErr(err)
+	test.AssertEqual(t, true, c3 != c2, "")
+	stats := p.Stats()
+	test.AssertEqual(t, int64(1), stats.Recycled, "")
+	test.AssertEqual(t, int64(2), stats.Created, "")
+	p.Release(c3)
+}
+
+func TestPoolConcurrent(t *testing.T) {
+	p := newTestPool(t, PoolOptions{Size: 3, MaxUses: 5})
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := p.Acquire(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer p.Release(c)
+			diagnostics, err := c.TypeCheck([]string{"/src/a.ts"}, nil)
+			if err != nil {
+				errs <- err
+			} else if len(diagnostics) != 1 {
+				errs <- errors.New("expect 1 diagnostic")
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	test.AssertEqual(t, int64(20), p.Stats().Acquired, "")
+}
+
+// run with -race, the compilers of the pool write to the same MemoryFS
+func TestPoolSharedMemoryFS(t *testing.T) {
+	p := newTestPool(t, PoolOptions{Size: 4})
+	var wg sync.WaitGroup
+	errs := make(chan error, 16)
+	for i := 0; i < 16; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c, err := p.Acquire(context.Background())
+			if err != nil {
+				errs <- err
+				return
+			}
+			defer p.Release(c)
+			src := fmt.Sprintf("/src/b%d.ts", i)
+			if err := p.fs.WriteFile(src, fmt.Sprintf("const b: number = %d;", i)); err != nil {
+				errs <- err
+				return
+			}
+			res, err := c.Emit([]string{src}, map[string]any{"outDir": fmt.Sprintf("/out/%d", i)})
+			if err != nil {
+				errs <- err
+			} else if len(res.EmittedFiles) != 1 {
+				errs <- fmt.Errorf("expect 1 emitted file, but got %d", len(res.EmittedFiles))
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		t.Error(err)
+	}
+	files, err := p.fs.Glob([]string{"/out/**/*.js"})
+	panicIfErr(err)
+	test.AssertEqual(t, 16, len(files), "")
+}
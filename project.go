@@ -0,0 +1,559 @@
+package v8tsgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+)
+
+// the codes of the config diagnostics, they are the ones of typescript
+const (
+	diagnosticJSONSyntax            = 1005
+	diagnosticOptionType            = 5024
+	diagnosticCannotRead            = 5083
+	diagnosticRootNotObject         = 5092
+	diagnosticOptionValue           = 6046
+	diagnosticFileNotFound          = 6053
+	diagnosticCircularReferences    = 6202
+	diagnosticReferenceNotComposite = 6306
+	diagnosticCircularExtends       = 18000
+	diagnosticEmptyFiles            = 18002
+	diagnosticNoInputs              = 18003
+)
+
+// the directories the wildcards never match, they must be included explicitly
+var implicitExcludes = map[string]bool{
+	"node_modules":     true,
+	"bower_components": true,
+	"jspm_packages":    true,
+}
+
+// ProjectReference mirrors ts.ProjectReference.
+type ProjectReference struct {
+	// Path is the absolute path of the tsconfig file of the referenced project.
+	Path     string `json:"path"`
+	Prepend  bool   `json:"prepend,omitempty"`
+	Circular bool   `json:"circular,omitempty"`
+}
+
+// Project is a tsconfig file with its extends chain resolved.
+type Project struct {
+	// ConfigPath is the absolute path of the tsconfig file.
+	ConfigPath string
+	// FileNames are the root files of the project, the files list followed by the files matching the include patterns.
+	FileNames []string
+	// Options are the compiler options merged along the extends chain.
+	Options    CompilerOptions
+	References []ProjectReference
+	// Diagnostics are the errors found in the config files, the project is usable in spite of them.
+	Diagnostics []Diagnostic
+}
+
+// configFile is the content of a tsconfig file merged with the files it extends,
+// the paths are absolute
+type configFile struct {
+	options map[string]any
+	// nil when not set
+	files   []string
+	include []string
+	exclude []string
+	// the configs the files, include and exclude patterns are inherited from
+	filesConfig   string
+	includeConfig string
+	excludeConfig string
+}
+
+type projectLoader struct {
+	fs          filesystem.FileSystem
+	diagnostics []Diagnostic
+	// the configs being loaded, to detect the circular extends
+	stack []string
+}
+
+func (l *projectLoader) report(fileName string, code int, format string, args ...any) {
+	l.diagnostics = append(l.diagnostics, Diagnostic{
+		File:     fileName,
+		Code:     code,
+		Category: DiagnosticCategoryError,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (l *projectLoader) fileExists(filePath string) bool {
+	exists, err := l.fs.FileExists(filePath)
+	return err == nil && exists
+}
+
+// resolvePath returns the absolute slash path of the path relative to the directory
+func resolvePath(dir string, p string) string {
+	if path.IsAbs(p) {
+		return path.Clean(p)
+	}
+	return path.Join(dir, p)
+}
+
+// resolveExtends resolves an extends entry like typescript does, the relative paths may omit ".json"
+// and the other ones are the packages under node_modules, or files in them.
+func (l *projectLoader) resolveExtends(configPath string, extends string) (string, bool) {
+	dir := path.Dir(configPath)
+	if path.IsAbs(extends) || strings.HasPrefix(extends, "./") || strings.HasPrefix(extends, "../") {
+		p := resolvePath(dir, extends)
+		if l.fileExists(p) {
+			return p, true
+		}
+		if !strings.HasSuffix(p, ".json") && l.fileExists(p+".json") {
+			return p + ".json", true
+		}
+		return "", false
+	}
+	for {
+		pkgPath := path.Join(dir, "node_modules", extends)
+		candidates := []string{pkgPath, pkgPath + ".json"}
+		var pkg struct {
+			Tsconfig string `json:"tsconfig"`
+		}
+		if text, err := l.fs.ReadFile(path.Join(pkgPath, "package.json"), "utf-8"); err == nil &&
+			json.Unmarshal([]byte(text), &pkg) == nil && pkg.Tsconfig != "" {
+			candidates = append(candidates, resolvePath(pkgPath, pkg.Tsconfig))
+		}
+		candidates = append(candidates, path.Join(pkgPath, "tsconfig.json"))
+		for _, candidate := range candidates {
+			if strings.HasSuffix(candidate, ".json") && l.fileExists(candidate) {
+				return candidate, true
+			}
+		}
+		if dir == "/" {
+			return "", false
+		}
+		dir = path.Dir(dir)
+	}
+}
+
+// stringList returns the strings of the json array, ok is false if it is not an array of strings
+func stringList(value any) ([]string, bool) {
+	values, ok := value.([]any)
+	if !ok {
+		return nil, false
+	}
+	out := make([]string, 0, len(values))
+	for _, v := range values {
+		s, ok := v.(string)
+		if !ok {
+			return nil, false
+		}
+		out = append(out, s)
+	}
+	return out, true
+}
+
+func (l *projectLoader) patterns(configPath string, raw map[string]any, key string) ([]string, bool) {
+	value, ok := raw[key]
+	if !ok || value == nil {
+		return nil, false
+	}
+	patterns, ok := stringList(value)
+	if !ok {
+		l.report(configPath, diagnosticOptionType, "Compiler option '%s' requires a value of type Array.", key)
+		return nil, false
+	}
+	dir := path.Dir(configPath)
+	for i, p := range patterns {
+		patterns[i] = resolvePath(dir, p)
+	}
+	return patterns, true
+}
+
+// resolveOptionPaths makes the path options absolute
+func resolveOptionPaths(dir string, options map[string]any) {
+	for name, value := range options {
+		if s, ok := value.(string); ok && compilerOptionPaths[name] {
+			options[name] = resolvePath(dir, s)
+		} else if list, ok := stringList(value); ok && compilerOptionPathLists[name] {
+			resolved := make([]any, len(list))
+			for i, p := range list {
+				resolved[i] = resolvePath(dir, p)
+			}
+			options[name] = resolved
+		}
+	}
+}
+
+// load reads the config and the ones it extends, the config at the root of the chain must be readable
+func (l *projectLoader) load(configPath string, raw map[string]any) *configFile {
+	config := &configFile{options: map[string]any{}}
+	l.stack = append(l.stack, configPath)
+	defer func() {
+		l.stack = l.stack[:len(l.stack)-1]
+	}()
+	var extends []string
+	switch value := raw["extends"].(type) {
+	case nil:
+	case string:
+		extends = []string{value}
+	default:
+		var ok bool
+		if extends, ok = stringList(value); !ok {
+			l.report(configPath, diagnosticOptionType, "Compiler option 'extends' requires a value of type string or Array.")
+		}
+	}
+	for _, entry := range extends {
+		basePath, ok := l.resolveExtends(configPath, entry)
+		if !ok {
+			l.report(configPath, diagnosticFileNotFound, "File '%s' not found.", entry)
+			continue
+		}
+		if circular := l.circular(basePath); circular != "" {
+			l.report(configPath, diagnosticCircularExtends, "Circularity detected while resolving configuration: %s", circular)
+			continue
+		}
+		baseRaw, ok := l.read(basePath)
+		if !ok {
+			continue
+		}
+		config.merge(l.load(basePath, baseRaw))
+	}
+	own := &configFile{options: map[string]any{}}
+	switch value := raw["compilerOptions"].(type) {
+	case nil:
+	case map[string]any:
+		own.options = value
+		resolveOptionPaths(path.Dir(configPath), own.options)
+		if _, ok := own.options["paths"]; ok {
+			// typescript resolves the paths against the tsconfig defining them if there is no baseUrl
+			own.options["pathsBasePath"] = path.Dir(configPath)
+		}
+	default:
+		l.report(configPath, diagnosticOptionType, "Compiler option 'compilerOptions' requires a value of type object.")
+	}
+	var ok bool
+	if own.files, ok = l.patterns(configPath, raw, "files"); ok {
+		own.filesConfig = configPath
+	}
+	if own.include, ok = l.patterns(configPath, raw, "include"); ok {
+		own.includeConfig = configPath
+	}
+	if own.exclude, ok = l.patterns(configPath, raw, "exclude"); ok {
+		own.excludeConfig = configPath
+	}
+	config.merge(own)
+	return config
+}
+
+func (l *projectLoader) circular(configPath string) string {
+	for i, p := range l.stack {
+		if p == configPath {
+			return strings.Join(append(append([]string{}, l.stack[i:]...), configPath), " -> ")
+		}
+	}
+	return ""
+}
+
+// read parses the config file, the errors are reported
+func (l *projectLoader) read(configPath string) (map[string]any, bool) {
+	text, err := l.fs.ReadFile(configPath, "utf-8")
+	if err != nil {
+		l.report(configPath, diagnosticCannotRead, "Cannot read file '%s'.", configPath)
+		return nil, false
+	}
+	var raw map[string]any
+	if d := parseJSONC(configPath, []byte(text), &raw); d != nil {
+		l.diagnostics = append(l.diagnostics, *d)
+		return nil, false
+	}
+	return raw, true
+}
+
+// merge overrides the config by the other one, a null option resets the inherited one
+func (c *configFile) merge(other *configFile) {
+	for name, value := range other.options {
+		if value == nil {
+			delete(c.options, name)
+		} else {
+			c.options[name] = value
+		}
+	}
+	if other.filesConfig != "" {
+		c.files, c.filesConfig = other.files, other.filesConfig
+	}
+	if other.includeConfig != "" {
+		c.include, c.includeConfig = other.include, other.includeConfig
+	}
+	if other.excludeConfig != "" {
+		c.exclude, c.excludeConfig = other.exclude, other.excludeConfig
+	}
+}
+
+// the extensions of the root files, by priority, a file is shadowed by a file with the same name
+// and an extension of a higher priority
+var (
+	tsExtensions  = []string{".ts", ".tsx", ".mts", ".cts", ".d.ts", ".d.mts", ".d.cts"}
+	jsExtensions  = []string{".js", ".jsx", ".mjs", ".cjs"}
+	dtsExtensions = []string{".d.ts", ".d.mts", ".d.cts"}
+)
+
+func boolOption(options map[string]any, name string) bool {
+	b, _ := options[name].(bool)
+	return b
+}
+
+// splitExtension returns the file name without its supported extension and the extension,
+// the extension is empty when the file is not supported
+func splitExtension(fileName string, extensions []string) (string, string) {
+	ext := ""
+	for _, e := range extensions {
+		// the longest one wins, so ".d.ts" is not taken for ".ts"
+		if strings.HasSuffix(fileName, e) && len(e) > len(ext) {
+			ext = e
+		}
+	}
+	return strings.TrimSuffix(fileName, ext), ext
+}
+
+func extensionPriority(ext string) int {
+	for _, e := range dtsExtensions {
+		if e == ext {
+			return 1
+		}
+	}
+	for _, e := range jsExtensions {
+		if e == ext {
+			return 2
+		}
+	}
+	return 0
+}
+
+// includeGlob converts the include or exclude pattern to a glob, the patterns without a wildcard
+// or an extension in their last part are directories
+func includeGlob(pattern string) string {
+	base := path.Base(pattern)
+	if !strings.ContainsAny(base, "*?") && path.Ext(base) == "" {
+		return path.Join(pattern, "**/*")
+	}
+	return pattern
+}
+
+// globBase returns the part of the pattern before its first wildcard
+func globBase(pattern string) string {
+	parts := strings.Split(pattern, "/")
+	for i, part := range parts {
+		if strings.ContainsAny(part, "*?[{") {
+			return strings.Join(parts[:i], "/")
+		}
+	}
+	return path.Dir(pattern)
+}
+
+// wildcardHidden reports whether the path matched by the wildcards of the pattern goes through
+// the implicitly excluded directories or the hidden files
+func wildcardHidden(pattern string, filePath string) bool {
+	rel := strings.TrimPrefix(filePath, globBase(pattern)+"/")
+	for _, part := range strings.Split(rel, "/") {
+		if implicitExcludes[part] || strings.HasPrefix(part, ".") {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *projectLoader) key(filePath string) string {
+	if l.fs.IsCaseSensitive() {
+		return filePath
+	}
+	return strings.ToLower(filePath)
+}
+
+// fileNames expands the files, include and exclude patterns of the config
+func (l *projectLoader) fileNames(configPath string, config *configFile) ([]string, error) {
+	extensions := tsExtensions
+	if boolOption(config.options, "allowJs") {
+		extensions = append(append([]string{}, tsExtensions...), jsExtensions...)
+	}
+	include := config.include
+	if config.includeConfig == "" && config.filesConfig == "" {
+		include = []string{path.Join(path.Dir(configPath), "**/*")}
+	}
+	exclude := config.exclude
+	if config.excludeConfig == "" {
+		exclude = []string{}
+		for _, name := range []string{"node_modules", "bower_components", "jspm_packages"} {
+			exclude = append(exclude, path.Join(path.Dir(configPath), name))
+		}
+		for _, name := range []string{"outDir", "declarationDir"} {
+			if dir, ok := config.options[name].(string); ok {
+				exclude = append(exclude, dir)
+			}
+		}
+	}
+	excludeGlobs := make([]string, 0, len(exclude)*2)
+	for _, pattern := range exclude {
+		excludeGlobs = append(excludeGlobs, pattern, includeGlob(pattern))
+	}
+	excluded := map[string]bool{}
+	if len(excludeGlobs) > 0 {
+		matches, err := l.fs.Glob(excludeGlobs)
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range matches {
+			excluded[l.key(match)] = true
+		}
+	}
+
+	var fileNames []string
+	seen := map[string]bool{}
+	for _, fileName := range config.files {
+		if !seen[l.key(fileName)] {
+			seen[l.key(fileName)] = true
+			fileNames = append(fileNames, fileName)
+		}
+	}
+	// the files matched by the include patterns, by their name without extension
+	var matched []string
+	byName := map[string][]string{}
+	for _, pattern := range include {
+		glob := includeGlob(pattern)
+		matches, err := l.fs.Glob([]string{glob})
+		if err != nil {
+			return nil, err
+		}
+		exts := extensions
+		if strings.HasSuffix(glob, ".json") && boolOption(config.options, "resolveJsonModule") {
+			exts = append(append([]string{}, extensions...), ".json")
+		}
+		for _, match := range matches {
+			key := l.key(match)
+			if seen[key] || excluded[key] || wildcardHidden(glob, match) {
+				continue
+			}
+			name, ext := splitExtension(match, exts)
+			if ext == "" {
+				continue
+			}
+			seen[key] = true
+			matched = append(matched, match)
+			byName[l.key(name)] = append(byName[l.key(name)], ext)
+		}
+	}
+	for _, match := range matched {
+		name, ext := splitExtension(match, append(append([]string{}, tsExtensions...), jsExtensions...))
+		shadowed := false
+		for _, other := range byName[l.key(name)] {
+			if extensionPriority(other) < extensionPriority(ext) {
+				shadowed = true
+			}
+		}
+		if !shadowed {
+			fileNames = append(fileNames, match)
+		}
+	}
+	return fileNames, nil
+}
+
+func (l *projectLoader) references(configPath string, raw map[string]any) []ProjectReference {
+	value, ok := raw["references"]
+	if !ok || value == nil {
+		return nil
+	}
+	entries, ok := value.([]any)
+	if !ok {
+		l.report(configPath, diagnosticOptionType, "Compiler option 'references' requires a value of type Array.")
+		return nil
+	}
+	var references []ProjectReference
+	for _, entry := range entries {
+		obj, _ := entry.(map[string]any)
+		refPath, ok := obj["path"].(string)
+		if !ok {
+			l.report(configPath, diagnosticOptionType, "Compiler option 'path' requires a value of type string.")
+			continue
+		}
+		refPath = resolvePath(path.Dir(configPath), refPath)
+		if !strings.HasSuffix(refPath, ".json") {
+			refPath = path.Join(refPath, "tsconfig.json")
+		}
+		prepend, _ := obj["prepend"].(bool)
+		circular, _ := obj["circular"].(bool)
+		references = append(references, ProjectReference{
+			Path:     refPath,
+			Prepend:  prepend,
+			Circular: circular,
+		})
+	}
+	return references
+}
+
+func formatPatterns(patterns []string) string {
+	if patterns == nil {
+		patterns = []string{}
+	}
+	data, _ := json.Marshal(patterns)
+	return string(data)
+}
+
+// resolveConfigPath returns the absolute path of the tsconfig file, the path may be relative to
+// the current directory or the directory of a tsconfig.json file
+func resolveConfigPath(fs filesystem.FileSystem, tsconfigPath string) (string, error) {
+	if !path.IsAbs(tsconfigPath) {
+		current, err := fs.GetCurrentDirectory()
+		if err != nil {
+			return "", fmt.Errorf("unable to load the project \"%s\", %w", tsconfigPath, err)
+		}
+		tsconfigPath = resolvePath(current, tsconfigPath)
+	}
+	tsconfigPath = path.Clean(tsconfigPath)
+	if isDir, err := fs.DirectoryExists(tsconfigPath); err == nil && isDir {
+		tsconfigPath = path.Join(tsconfigPath, "tsconfig.json")
+	}
+	return tsconfigPath, nil
+}
+
+// LoadProject loads the tsconfig file from the file system. The comments and the trailing commas are allowed,
+// the extends chain is followed, and the files, include and exclude patterns are expanded with the Glob
+// of the file system. The path may be the directory of a tsconfig.json file. An error is only returned
+// when the tsconfig file can't be read, the problems of its content are reported by the Diagnostics.
+func LoadProject(fs filesystem.FileSystem, tsconfigPath string) (*Project, error) {
+	tsconfigPath, err := resolveConfigPath(fs, tsconfigPath)
+	if err != nil {
+		return nil, err
+	}
+	text, err := fs.ReadFile(tsconfigPath, "utf-8")
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the project \"%s\", %w", tsconfigPath, err)
+	}
+	l := &projectLoader{fs: fs}
+	project := &Project{ConfigPath: tsconfigPath}
+	raw := map[string]any{}
+	if d := parseJSONC(tsconfigPath, []byte(text), &raw); d != nil {
+		l.diagnostics = append(l.diagnostics, *d)
+	}
+	config := l.load(tsconfigPath, raw)
+	project.References = l.references(tsconfigPath, raw)
+	config.options["configFilePath"] = tsconfigPath
+	var diagnostics []Diagnostic
+	project.Options, diagnostics = decodeCompilerOptions(tsconfigPath, config.options)
+	l.diagnostics = append(l.diagnostics, diagnostics...)
+
+	project.FileNames, err = l.fileNames(tsconfigPath, config)
+	if err != nil {
+		return nil, fmt.Errorf("unable to load the project \"%s\", %w", tsconfigPath, err)
+	}
+	_, hasFiles := raw["files"]
+	_, hasReferences := raw["references"]
+	if config.filesConfig != "" && len(config.files) == 0 && config.includeConfig == "" && !hasReferences {
+		l.report(tsconfigPath, diagnosticEmptyFiles, "The 'files' list in config file '%s' is empty.", tsconfigPath)
+	} else if len(project.FileNames) == 0 && !hasFiles && !hasReferences {
+		include := config.include
+		if config.includeConfig == "" {
+			include = []string{"**/*"}
+		}
+		l.report(tsconfigPath, diagnosticNoInputs,
+			"No inputs were found in config file '%s'. Specified 'include' paths were '%s' and 'exclude' paths were '%s'.",
+			tsconfigPath, formatPatterns(include), formatPatterns(config.exclude))
+	}
+	project.Diagnostics = l.diagnostics
+	return project, nil
+}
@@ -0,0 +1,183 @@
+package v8tsgo
+
+import (
+	"errors"
+	"io/fs"
+	"strings"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func newProjectFS(files map[string]string) *filesystem.MemoryFS {
+	mfs := filesystem.NewMemoryFS(true)
+	for path, content := range files {
+		panicIfErr(mfs.Mkdir(path[0 : strings.LastIndex(path, "/")+1]))
+		panicIfErr(mfs.WriteFile(path, content))
+	}
+	return mfs
+}
+
+func diagnosticsString(diagnostics []Diagnostic) string {
+	var lines []string
+	for _, d := range diagnostics {
+		lines = append(lines, d.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestLoadProject(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/repo/tsconfig.base.json": `{
+			// the shared options
+			"compilerOptions": {
+				"target": "es2020",
+				"strict": true,
+				"outDir": "./out", /* relative to this file */
+				"lib": ["dom"],
+				"paths": {"@/*": ["./src/*"]},
+			},
+			"exclude": ["**/*.spec.ts"],
+		}`,
+		"/repo/node_modules/@org/tsconfig/package.json": `{"name": "@org/tsconfig", "tsconfig": "base.json"}`,
+		"/repo/node_modules/@org/tsconfig/base.json":    `{"compilerOptions": {"module": "esnext", "declaration": true, "customOption": 1}}`,
+		"/repo/node_modules/@org/strict/tsconfig.json":  `{"compilerOptions": {"noImplicitAny": false}}`,
+		"/repo/app/tsconfig.json": `{
+			"extends": ["@org/tsconfig", "@org/strict/tsconfig.json", "../tsconfig.base"],
+			"compilerOptions": {
+				"allowJs": true,
+				"lib": null,
+				"rootDir": "src",
+			},
+			"files": ["types/global.d.ts"],
+			"include": ["src"],
+			"references": [{"path": "../lib"}, {"path": "../other/tsconfig.app.json", "prepend": true}],
+		}`,
+		"/repo/app/types/global.d.ts":            "",
+		"/repo/app/src/index.ts":                 "",
+		"/repo/app/src/index.spec.ts":            "",
+		"/repo/app/src/util.js":                  "",
+		"/repo/app/src/shadowed.ts":              "",
+		"/repo/app/src/shadowed.js":              "",
+		"/repo/app/src/shadowed.d.ts":            "",
+		"/repo/app/src/readme.md":                "",
+		"/repo/app/src/.hidden/a.ts":             "",
+		"/repo/app/src/node_modules/dep/a.ts":    "",
+		"/repo/app/src/nested/component.tsx":     "",
+		"/repo/app/src/nested/component.d.ts":    "",
+		"/repo/app/src/nested/declarations.d.ts": "",
+	})
+	panicIfErr(mfs.Mkdir("/repo/app"))
+
+	project, err := LoadProject(mfs, "/repo/app")
+	panicIfErr(err)
+	test.AssertEqual(t, "", diagnosticsString(project.Diagnostics), "")
+	test.AssertEqual(t, "/repo/app/tsconfig.json", project.ConfigPath, "")
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/app/types/global.d.ts",
+		"/repo/app/src/index.ts",
+		"/repo/app/src/nested/component.tsx",
+		"/repo/app/src/nested/declarations.d.ts",
+		"/repo/app/src/shadowed.ts",
+		"/repo/app/src/util.js",
+	}, ","), strings.Join(project.FileNames, ","), "")
+
+	options := project.Options
+	test.AssertEqual(t, ScriptTargetES2020, *options.Target, "")
+	test.AssertEqual(t, ModuleKindESNext, *options.Module, "")
+	test.AssertEqual(t, true, *options.Strict, "")
+	test.AssertEqual(t, true, *options.Declaration, "")
+	test.AssertEqual(t, false, *options.NoImplicitAny, "")
+	test.AssertEqual(t, true, *options.AllowJs, "")
+	test.AssertEqual(t, true, options.Lib == nil, "")
+	test.AssertEqual(t, "/repo/out", options.OutDir, "")
+	test.AssertEqual(t, "/repo/app/src", options.RootDir, "")
+	test.AssertEqual(t, "/repo/app/tsconfig.json", options.ConfigFilePath, "")
+	test.AssertEqual(t, "./src/*", options.Paths["@/*"][0], "")
+	test.AssertEqual(t, "/repo", options.PathsBasePath, "")
+	test.AssertEqual(t, "/repo", options.ToAPI()["pathsBasePath"], "")
+	test.AssertEqual(t, float64(1), options.Extra["customOption"].(float64), "")
+
+	test.MustEqual(t, 2, len(project.References), "")
+	test.AssertEqual(t, ProjectReference{Path: "/repo/lib/tsconfig.json"}, project.References[0], "")
+	test.AssertEqual(t, ProjectReference{Path: "/repo/other/tsconfig.app.json", Prepend: true}, project.References[1], "")
+}
+
+func TestLoadProjectDefaults(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/proj/tsconfig.json":               `{"compilerOptions": {"outDir": "dist", "resolveJsonModule": true}}`,
+		"/proj/a.ts":                        "",
+		"/proj/data.json":                   "",
+		"/proj/dist/a.d.ts":                 "",
+		"/proj/node_modules/dep/index.ts":   "",
+		"/proj/lib/b.mts":                   "",
+		"/proj/lib/c.d.cts":                 "",
+		"/proj/bower_components/x/index.ts": "",
+	})
+	panicIfErr(mfs.Mkdir("/proj"))
+	project, err := LoadProject(mfs, "tsconfig.json")
+	test.MustEqual(t, true, err != nil, "")
+	test.AssertEqual(t, true, errors.Is(err, fs.ErrNotExist), "")
+
+	project, err = LoadProject(mfs, "/proj/tsconfig.json")
+	panicIfErr(err)
+	test.AssertEqual(t, "", diagnosticsString(project.Diagnostics), "")
+	// the json files must be included explicitly
+	test.AssertEqual(t, "/proj/a.ts,/proj/lib/b.mts,/proj/lib/c.d.cts", strings.Join(project.FileNames, ","), "")
+}
+
+func TestLoadProjectDiagnostics(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/proj/tsconfig.json": `{
+			"extends": ["./a", "missing-package", "./missing.json"],
+			"compilerOptions": {"strict": "yes", "outDir": 1},
+			"include": ["src"]
+		}`,
+		"/proj/a.json":        `{"extends": "./b.json"}`,
+		"/proj/b.json":        `{"extends": "./a.json"}`,
+		"/proj/bad.json":      "{\n  \"compilerOptions\": {\n    \"strict\": true\n  \n}",
+		"/proj/array.json":    `[]`,
+		"/proj/empty.json":    `{"files": []}`,
+		"/proj/solution.json": `{"files": [], "references": [{"path": "./app"}]}`,
+	})
+	project, err := LoadProject(mfs, "/proj/tsconfig.json")
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/proj/b.json: error TS18000: Circularity detected while resolving configuration: /proj/a.json -> /proj/b.json -> /proj/a.json",
+		"/proj/tsconfig.json: error TS6053: File 'missing-package' not found.",
+		"/proj/tsconfig.json: error TS6053: File './missing.json' not found.",
+		"/proj/tsconfig.json: error TS5024: Compiler option 'outDir' requires a value of type string.",
+		"/proj/tsconfig.json: error TS5024: Compiler option 'strict' requires a value of type boolean.",
+		`/proj/tsconfig.json: error TS18003: No inputs were found in config file '/proj/tsconfig.json'. Specified 'include' paths were '["/proj/src"]' and 'exclude' paths were '[]'.`,
+	}, "\n"), diagnosticsString(project.Diagnostics), "")
+	test.AssertEqual(t, true, project.Options.Strict == nil, "")
+
+	project, err = LoadProject(mfs, "/proj/bad.json")
+	panicIfErr(err)
+	test.MustEqual(t, true, len(project.Diagnostics) > 0, "")
+	test.AssertEqual(t, 1005, project.Diagnostics[0].Code, "")
+	test.AssertEqual(t, 5, project.Diagnostics[0].Line, "")
+
+	project, err = LoadProject(mfs, "/proj/array.json")
+	panicIfErr(err)
+	test.MustEqual(t, true, len(project.Diagnostics) > 0, "")
+	test.AssertEqual(t, "/proj/array.json(1,1): error TS5092: The root value of a 'array.json' file must be an object.", project.Diagnostics[0].String(), "")
+
+	project, err = LoadProject(mfs, "/proj/empty.json")
+	panicIfErr(err)
+	test.AssertEqual(t, "/proj/empty.json: error TS18002: The 'files' list in config file '/proj/empty.json' is empty.", diagnosticsString(project.Diagnostics), "")
+
+	project, err = LoadProject(mfs, "/proj/solution.json")
+	panicIfErr(err)
+	test.AssertEqual(t, "", diagnosticsString(project.Diagnostics), "")
+	test.AssertEqual(t, 0, len(project.FileNames), "")
+	test.AssertEqual(t, "/proj/app/tsconfig.json", project.References[0].Path, "")
+}
+
+func TestStripJSONC(t *testing.T) {
+	src := "{\n  // comment \"x\"\n  \"a\": \"// not a comment\", /* block\n comment */\n  \"b\": [1, 2,],\n}"
+	out := stripJSONC([]byte(src))
+	test.AssertEqual(t, len(src), len(out), "")
+	test.AssertEqual(t, "{\n                \n  \"a\": \"// not a comment\",         \n           \n  \"b\": [1, 2 ] \n}", string(out), "")
+}
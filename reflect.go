@@ -0,0 +1,318 @@
+package v8tsgo
+
+import (
+	"encoding"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"rogchap.com/v8go"
+)
+
+// the nested values deeper than this are most likely cycles
+const maxValueDepth = 1000
+
+var (
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// structField is an exported field of a struct, named and filtered by its json tag
+type structField struct {
+	name      string
+	index     []int
+	omitEmpty bool
+}
+
+var structFieldsCache sync.Map
+
+func parseJsonTag(field reflect.StructField) (name string, omitEmpty bool, skip bool) {
+	tag, ok := field.Tag.Lookup("json")
+	if !ok {
+		return "", false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+	name, opts, _ := strings.Cut(tag, ",")
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == "omitempty" {
+			omitEmpty = true
+		}
+	}
+	return name, omitEmpty, false
+}
+
+// structFields returns the fields encoding/json would encode, the fields of the embedded structs without
+// a json name are promoted unless a shallower field has the same name.
+func structFields(t reflect.Type) []structField {
+	if cached, ok := structFieldsCache.Load(t); ok {
+		return cached.([]structField)
+	}
+	var fields []structField
+	names := map[string]bool{}
+	current := []structField{{}}
+	visited := map[reflect.Type]bool{}
+	for len(current) > 0 {
+		var next []structField
+		var level []structField
+		for _, parent := range current {
+			pt := t
+			if len(parent.index) > 0 {
+				pt = t.FieldByIndex(parent.index).Type
+				if pt.Kind() == reflect.Pointer {
+					pt = pt.Elem()
+				}
+			}
+			if visited[pt] {
+				continue
+			}
+			visited[pt] = true
+			for i := 0; i < pt.NumField(); i++ {
+				f := pt.Field(i)
+				name, omitEmpty, skip := parseJsonTag(f)
+				if skip {
+					continue
+				}
+				index := append(append([]int{}, parent.index...), i)
+				if f.Anonymous && name == "" {
+					ft := f.Type
+					if ft.Kind() == reflect.Pointer {
+						ft = ft.Elem()
+					}
+					if ft.Kind() == reflect.Struct {
+						// as encoding/json, the fields of an unexported embedded struct pointer are ignored
+						if f.IsExported() || f.Type.Kind() == reflect.Struct {
+							next = append(next, structField{index: index})
+						}
+						continue
+					}
+				}
+				if !f.IsExported() {
+					continue
+				}
+				if name == "" {
+					name = f.Name
+				}
+				level = append(level, structField{name: name, index: index, omitEmpty: omitEmpty})
+			}
+		}
+		for _, f := range level {
+			if !names[f.name] {
+				names[f.name] = true
+				fields = append(fields, f)
+			}
+		}
+		current = next
+	}
+	structFieldsCache.Store(t, fields)
+	return fields
+}
+
+func isEmptyValue(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return v.Len() == 0
+	case reflect.Bool:
+		return !v.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return v.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return v.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return v.Float() == 0
+	case reflect.Interface, reflect.Pointer:
+		return v.IsNil()
+	}
+	return false
+}
+
+// fieldByIndex is reflect.Value.FieldByIndex, but it reports false instead of panicking on a nil embedded pointer
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 && v.Kind() == reflect.Pointer {
+			if v.IsNil() {
+				return reflect.Value{}, false
+			}
+			v = v.Elem()
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
+
+func newObject(ctx *v8go.Context) (*v8go.Object, error) {
+	return v8go.NewObjectTemplate(ctx.Isolate()).NewInstance(ctx)
+}
+
+func newArray(ctx *v8go.Context, length int) (*v8go.Object, error) {
+	valArray, err := ctx.Global().Get("Array")
+	if err != nil {
+		return nil, err
+	}
+	fnArray, err := valArray.AsFunction()
+	if err != nil {
+		return nil, err
+	}
+	valLength, err := v8go.NewValue(ctx.Isolate(), uint32(length))
+	if err != nil {
+		return nil, err
+	}
+	return fnArray.NewInstance(valLength)
+}
+
+// makeElemValue converts the field, element or map value. Unlike the top level, a float32 keeps its
+// shortest decimal form as encoding/json does, so 3.14 stays 3.14 instead of 3.140000104904175, and
+// the 64 bits integers are numbers as encoding/json makes them, unless they are not safe integers,
+// which become bigints to keep their precision.
+func makeElemValue(ctx *v8go.Context, v reflect.Value, depth int) (*v8go.Value, error) {
+	elem := v
+	for (elem.Kind() == reflect.Pointer || elem.Kind() == reflect.Interface) && !elem.IsNil() {
+		elem = elem.Elem()
+	}
+	switch elem.Kind() {
+	case reflect.Float32:
+		f, err := strconv.ParseFloat(strconv.FormatFloat(elem.Float(), 'g', -1, 32), 64)
+		if err != nil {
+			return nil, err
+		}
+		return v8go.NewValue(ctx.Isolate(), f)
+	case reflect.Int, reflect.Int64:
+		if n := elem.Int(); n >= -maxSafeInteger && n <= maxSafeInteger {
+			return v8go.NewValue(ctx.Isolate(), float64(n))
+		}
+	case reflect.Uint, reflect.Uint64:
+		if n := elem.Uint(); n <= maxSafeInteger {
+			return v8go.NewValue(ctx.Isolate(), float64(n))
+		}
+	}
+	if !v.CanInterface() {
+		return nil, fmt.Errorf("unable to access the unexported value of %s", v.Type())
+	}
+	return makeValue(ctx, v.Interface(), depth)
+}
+
+func mapKey(k reflect.Value) (string, error) {
+	if k.Kind() == reflect.String {
+		return k.String(), nil
+	}
+	if k.Type().Implements(textMarshalerType) {
+		text, err := k.Interface().(encoding.TextMarshaler).MarshalText()
+		return string(text), err
+	}
+	switch k.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(k.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return strconv.FormatUint(k.Uint(), 10), nil
+	}
+	return "", fmt.Errorf("unsupported map key type %s", k.Type())
+}
+
+// makeReflectValue converts the structs, maps, slices and arrays, their elements follow the rules of MakeValue.
+func makeReflectValue(ctx *v8go.Context, rv reflect.Value, depth int) (*v8go.Value, error) {
+	iso := ctx.Isolate()
+	if depth > maxValueDepth {
+		return nil, fmt.Errorf("the value of %s is nested too deeply, it may contain a cycle", rv.Type())
+	}
+	if rv.Type().Implements(jsonMarshalerType) || rv.CanAddr() && reflect.PointerTo(rv.Type()).Implements(jsonMarshalerType) {
+		marshaler := rv.Interface()
+		if rv.CanAddr() {
+			marshaler = rv.Addr().Interface()
+		}
+		j, err := json.Marshal(marshaler)
+		if err != nil {
+			return nil, fmt.Errorf("unable to marshal %s to json when making a v8 value, %w", rv.Type(), err)
+		}
+		return v8go.JSONParse(ctx, string(j))
+	}
+	switch rv.Kind() {
+	case reflect.Struct:
+		obj, err := newObject(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the object of %s, %w", rv.Type(), err)
+		}
+		for _, f := range structFields(rv.Type()) {
+			fv, ok := fieldByIndex(rv, f.index)
+			if !ok || f.omitEmpty && isEmptyValue(fv) {
+				continue
+			}
+			value, err := makeElemValue(ctx, fv, depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("unable to make the value of the field \"%s\", %w", f.name, err)
+			}
+			err = obj.Set(f.name, value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to set the field \"%s\", %w", f.name, err)
+			}
+		}
+		return obj.Value, nil
+	case reflect.Map:
+		if rv.IsNil() {
+			return v8go.Null(iso), nil
+		}
+		obj, err := newObject(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the object of %s, %w", rv.Type(), err)
+		}
+		keys := make([]string, 0, rv.Len())
+		values := make(map[string]reflect.Value, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			key, err := mapKey(iter.Key())
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values[key] = iter.Value()
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			value, err := makeElemValue(ctx, values[key], depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("unable to make the value of the key \"%s\", %w", key, err)
+			}
+			err = obj.Set(key, value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to set the key \"%s\", %w", key, err)
+			}
+		}
+		return obj.Value, nil
+	case reflect.Slice, reflect.Array:
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return v8go.Null(iso), nil
+		}
+		if rv.Kind() == reflect.Slice && rv.Type().Elem().Kind() == reflect.Uint8 {
+			// encoded as a base64 string, as encoding/json does
+			return v8go.NewValue(iso, base64.StdEncoding.EncodeToString(rv.Bytes()))
+		}
+		length := rv.Len()
+		if length > math.MaxUint32 {
+			return nil, fmt.Errorf("the length %d is too large for a js array", length)
+		}
+		arr, err := newArray(ctx, length)
+		if err != nil {
+			return nil, fmt.Errorf("unable to create the array of %s, %w", rv.Type(), err)
+		}
+		for i := 0; i < length; i++ {
+			value, err := makeElemValue(ctx, rv.Index(i), depth+1)
+			if err != nil {
+				return nil, fmt.Errorf("unable to make the value of the element %d, %w", i, err)
+			}
+			err = arr.SetIdx(uint32(i), value)
+			if err != nil {
+				return nil, fmt.Errorf("unable to set the element %d, %w", i, err)
+			}
+		}
+		return arr.Value, nil
+	}
+	return nil, fmt.Errorf("unsupported type %s", rv.Type())
+}
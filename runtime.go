@@ -0,0 +1,105 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"reflect"
+
+	v8 "rogchap.com/v8go"
+)
+
+// Runtime is a v8 context prepared for the hosts of this package,
+// it must only be used by one goroutine at a time.
+type Runtime struct {
+	ctx   *v8.Context
+	utils *V8Utils
+	// the classes bound to the struct types
+	classes map[reflect.Type]*classBinding
+}
+
+func NewRuntime(ctx *v8.Context) (*Runtime, error) {
+	utils, err := NewV8Utils(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return &Runtime{
+		ctx:     ctx,
+		utils:   utils,
+		classes: map[reflect.Type]*classBinding{},
+	}, nil
+}
+
+func (r *Runtime) Context() *v8.Context {
+	return r.ctx
+}
+
+func (r *Runtime) Utils() *V8Utils {
+	return r.utils
+}
+
+// Loop returns the event loop of the context, the owner of the runtime drives it.
+func (r *Runtime) Loop() *Loop {
+	return r.utils.loop
+}
+
+var ErrPromiseNeverSettles = errors.New("the promise is pending but the loop is idle, it will never settle")
+
+// PromiseRejectedError is returned by Await when the promise is rejected.
+type PromiseRejectedError struct {
+	// Message is the message of the rejected error, or the string form of the reason if it is not an object.
+	Message string
+	// Stack is empty if the reason has no stack.
+	Stack string
+	// Reason is the rejection reason, it is only valid while the runtime is alive.
+	Reason *v8.Value
+	// Exception is the reason converted to go, it is returned by Unwrap.
+	Exception *JSException
+}
+
+func (e *PromiseRejectedError) Error() string {
+	return "promise rejected: " + e.Message
+}
+
+func (e *PromiseRejectedError) Unwrap() error {
+	return e.Exception
+}
+
+func newPromiseRejectedError(reason *v8.Value) *PromiseRejectedError {
+	ex := NewJSException(reason)
+	return &PromiseRejectedError{
+		Message:   ex.Message,
+		Stack:     ex.Stack,
+		Reason:    reason,
+		Exception: ex,
+	}
+}
+
+// Await drives the loop until the promise settles and returns its result.
+// A value which is not a promise is returned as is. A rejection is returned as *PromiseRejectedError,
+// and ErrPromiseNeverSettles is returned if nothing left in the loop can settle the promise.
+func (r *Runtime) Await(ctx context.Context, promise *v8.Value) (*v8.Value, error) {
+	if !promise.IsPromise() {
+		return promise, nil
+	}
+	p, err := promise.AsPromise()
+	if err != nil {
+		return nil, err
+	}
+	loop := r.Loop()
+	for {
+		loop.RunOnce()
+		switch p.State() {
+		case v8.Fulfilled:
+			return p.Result(), nil
+		case v8.Rejected:
+			return nil, newPromiseRejectedError(p.Result())
+		}
+		if loop.Idle() {
+			return nil, ErrPromiseNeverSettles
+		}
+		err = loop.Wait(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package v8tsgo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestAwait(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/src"))
+	panicIfErr(mfs.WriteFile("/src/a.ts", ""))
+	panicIfErr(mfs.WriteFile("/src/b.ts", ""))
+	rt := newTestRuntime(t, mfs)
+	ctx := rt.Context()
+
+	promise, err := ctx.RunScript("host.glob('/src/*.ts')", "glob.js")
+	panicIfErr(err)
+	v, err := rt.Await(context.Background(), promise)
+	panicIfErr(err)
+	var files []string
+	panicIfErr(ParseValue(ctx, v, &files))
+	test.AssertEqual(t, 2, len(files), "")
+
+	v, err = rt.Await(context.Background(), mustNewValue(ctx.Isolate(), "plain"))
+	panicIfErr(err)
+	test.AssertEqual(t, "plain", v.String(), "")
+}
+
+func TestAwaitRejected(t *testing.T) {
+	rt := newTestRuntime(t, filesystem.NewMemoryFS(true))
+	ctx := rt.Context()
+
+	promise, err := ctx.RunScript(`
+		async function fail() {
+			await host.readFile('/missing.txt');
+		}
+		fail().catch((e) => { throw new TypeError('wrapped: ' + e.message); })
+	`, "reject.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	var rejected *PromiseRejectedError
+	test.MustEqual(t, true, errors.As(err, &rejected), "")
+	test.AssertEqual(t, true, strings.HasPrefix(rejected.Message, "wrapped: "), "")
+	test.AssertEqual(t, true, strings.Contains(rejected.Stack, "TypeError"), "")
+	test.AssertEqual(t, true, strings.Contains(rejected.Stack, "reject.js"), "")
+
+	promise, err = ctx.RunScript("Promise.reject('plain reason')", "reject-string.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	test.MustEqual(t, true, errors.As(err, &rejected), "")
+	test.AssertEqual(t, "plain reason", rejected.Message, "")
+	test.AssertEqual(t, "", rejected.Stack, "")
+}
+
+func TestAwaitNeverSettles(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	promise, err := rt.Context().RunScript("new Promise(() => {})", "pending.js")
+	panicIfErr(err)
+	_, err = rt.Await(context.Background(), promise)
+	test.AssertEqual(t, ErrPromiseNeverSettles, err, "")
+}
+
+func TestAwaitCanceled(t *testing.T) {
+	rt := newTestRuntime(t, nil)
+	resolver := mustMakeResolver(rt.Context())
+	release := make(chan struct{})
+	rt.Loop().Go(func() func() {
+		<-release
+		return func() {
+			resolver.Resolve(mustNewValue(rt.Context().Isolate(), "late"))
+		}
+	})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err := rt.Await(ctx, resolver.GetPromise().Value)
+	test.AssertEqual(t, context.DeadlineExceeded, err, "")
+	close(release)
+	v, err := rt.Await(context.Background(), resolver.GetPromise().Value)
+	panicIfErr(err)
+	test.AssertEqual(t, "late", v.String(), "")
+}
@@ -0,0 +1,519 @@
+package v8tsgo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+)
+
+// ProjectStatus is the state of a project after BuildSolution.
+type ProjectStatus int
+
+const (
+	// ProjectUpToDate means the outputs are newer than the inputs, the project is not built.
+	ProjectUpToDate ProjectStatus = iota
+	// ProjectBuilt means the project is built without errors.
+	ProjectBuilt
+	// ProjectBuildErrors means the build reported errors, nothing is emitted.
+	ProjectBuildErrors
+	// ProjectConfigErrors means the tsconfig file has errors, the project is not built.
+	ProjectConfigErrors
+	// ProjectUpstreamBlocked means a referenced project has errors, the project is not built.
+	ProjectUpstreamBlocked
+)
+
+func (s ProjectStatus) String() string {
+	switch s {
+	case ProjectUpToDate:
+		return "up to date"
+	case ProjectBuilt:
+		return "built"
+	case ProjectBuildErrors:
+		return "build errors"
+	case ProjectConfigErrors:
+		return "config errors"
+	case ProjectUpstreamBlocked:
+		return "upstream blocked"
+	default:
+		return fmt.Sprintf("ProjectStatus(%d)", int(s))
+	}
+}
+
+func (s ProjectStatus) failed() bool {
+	return s == ProjectBuildErrors || s == ProjectConfigErrors || s == ProjectUpstreamBlocked
+}
+
+// ProjectBuildResult is the result of a project of the solution.
+type ProjectBuildResult struct {
+	// ConfigPath is the absolute path of the tsconfig file.
+	ConfigPath string
+	Status     ProjectStatus
+	// Reason explains the status, such as the input newer than the outputs which causes the build.
+	Reason string
+	// Project is nil when the tsconfig file is not found.
+	Project      *Project
+	EmittedFiles []string
+	// Diagnostics are the ones of the tsconfig file followed by the ones of the build.
+	Diagnostics []Diagnostic
+
+	upstream []*ProjectBuildResult
+	// the time of the newest output, to check the projects referencing this one
+	newestOutput time.Time
+}
+
+// SolutionResult is the result of BuildSolution.
+type SolutionResult struct {
+	// Projects are in build order, the referenced projects come before the projects referencing them.
+	Projects []*ProjectBuildResult
+}
+
+// HasErrors reports whether a project of the solution failed.
+func (r *SolutionResult) HasErrors() bool {
+	for _, p := range r.Projects {
+		if p.Status.failed() {
+			return true
+		}
+	}
+	return false
+}
+
+// BuildOptions are the options of BuildSolution.
+type BuildOptions struct {
+	// Force builds the up to date projects too, like tsc -b --force.
+	Force bool
+	// OnProject is called once the status of a project is known, in build order.
+	OnProject func(result *ProjectBuildResult)
+	// CompilerOptions configure the Compiler the projects are built with, it is only created when a project needs a build.
+	CompilerOptions []CompilerOption
+}
+
+type solutionBuilder struct {
+	fs       filesystem.FileSystem
+	opts     BuildOptions
+	compiler *Compiler
+	results  map[string]*ProjectBuildResult
+	order    []*ProjectBuildResult
+	// the configs being visited, to detect the circular references
+	stack []string
+}
+
+func (b *solutionBuilder) report(r *ProjectBuildResult, code int, format string, args ...any) {
+	r.Diagnostics = append(r.Diagnostics, Diagnostic{
+		File:     r.ConfigPath,
+		Code:     code,
+		Category: DiagnosticCategoryError,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// visit loads the project and the projects it references, they are added to the build order after their references
+func (b *solutionBuilder) visit(configPath string) (*ProjectBuildResult, error) {
+	if r, ok := b.results[configPath]; ok {
+		return r, nil
+	}
+	r := &ProjectBuildResult{ConfigPath: configPath}
+	b.results[configPath] = r
+	project, err := LoadProject(b.fs, configPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		b.report(r, diagnosticFileNotFound, "File '%s' not found.", configPath)
+		b.order = append(b.order, r)
+		return r, nil
+	} else if err != nil {
+		return nil, err
+	}
+	r.Project = project
+	r.Diagnostics = append(r.Diagnostics, project.Diagnostics...)
+	b.stack = append(b.stack, configPath)
+	defer func() {
+		b.stack = b.stack[:len(b.stack)-1]
+	}()
+	for _, ref := range project.References {
+		if i := indexOf(b.stack, ref.Path); i >= 0 {
+			if !ref.Circular {
+				cycle := append(append([]string{}, b.stack[i:]...), ref.Path)
+				b.report(r, diagnosticCircularReferences, "Project references may not form a circular graph. Cycle detected: %s", strings.Join(cycle, "\n"))
+			}
+			continue
+		}
+		upstream, err := b.visit(ref.Path)
+		if err != nil {
+			return nil, err
+		}
+		// the solutions without inputs may reference any project
+		if upstream.Project != nil && !isTrue(upstream.Project.Options.Composite) && len(project.FileNames) > 0 {
+			b.report(r, diagnosticReferenceNotComposite, "Referenced project '%s' must have setting \"composite\": true.", ref.Path)
+		}
+		r.upstream = append(r.upstream, upstream)
+	}
+	b.order = append(b.order, r)
+	return r, nil
+}
+
+func indexOf(list []string, s string) int {
+	for i, e := range list {
+		if e == s {
+			return i
+		}
+	}
+	return -1
+}
+
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
+func hasErrors(diagnostics []Diagnostic) bool {
+	for _, d := range diagnostics {
+		if d.Category == DiagnosticCategoryError {
+			return true
+		}
+	}
+	return false
+}
+
+// relativePath returns the slash path of the target relative to the directory, both are absolute
+func relativePath(dir string, target string) string {
+	dirParts := strings.Split(strings.Trim(path.Clean(dir), "/"), "/")
+	targetParts := strings.Split(strings.Trim(path.Clean(target), "/"), "/")
+	if dirParts[0] == "" {
+		dirParts = nil
+	}
+	i := 0
+	for i < len(dirParts) && i < len(targetParts) && dirParts[i] == targetParts[i] {
+		i++
+	}
+	parts := make([]string, 0, len(dirParts)-i+len(targetParts)-i)
+	for range dirParts[i:] {
+		parts = append(parts, "..")
+	}
+	return path.Join(append(parts, targetParts[i:]...)...)
+}
+
+// buildInfoPath returns the path of the .tsbuildinfo file the way typescript derives it,
+// it is empty when the project is not incremental
+func buildInfoPath(project *Project) string {
+	o := project.Options
+	if o.TsBuildInfoFile != "" {
+		return o.TsBuildInfoFile
+	}
+	if !isTrue(o.Composite) && !isTrue(o.Incremental) {
+		return ""
+	}
+	if o.OutFile != "" {
+		return strings.TrimSuffix(o.OutFile, path.Ext(o.OutFile)) + ".tsbuildinfo"
+	}
+	name := strings.TrimSuffix(project.ConfigPath, path.Ext(project.ConfigPath))
+	if o.OutDir != "" {
+		if o.RootDir != "" {
+			name = path.Join(o.OutDir, relativePath(o.RootDir, name))
+		} else {
+			name = path.Join(o.OutDir, path.Base(name))
+		}
+	}
+	return name + ".tsbuildinfo"
+}
+
+// commonSourceDir returns the directory the outputs are relative to
+func commonSourceDir(project *Project) string {
+	o := project.Options
+	if o.RootDir != "" {
+		return o.RootDir
+	}
+	if isTrue(o.Composite) {
+		return path.Dir(project.ConfigPath)
+	}
+	var dir string
+	for _, name := range project.FileNames {
+		if _, ext := splitExtension(name, dtsExtensions); ext != "" {
+			continue
+		}
+		if dir == "" {
+			dir = path.Dir(name)
+			continue
+		}
+		for dir != "/" && !strings.HasPrefix(name, dir+"/") {
+			dir = path.Dir(dir)
+		}
+	}
+	return dir
+}
+
+// the extensions of the javascript and the declaration outputs of the input extensions
+var outputExtensions = map[string][2]string{
+	".ts":  {".js", ".d.ts"},
+	".tsx": {".js", ".d.ts"},
+	".mts": {".mjs", ".d.mts"},
+	".cts": {".cjs", ".d.cts"},
+	".js":  {".js", ".d.ts"},
+	".jsx": {".js", ".d.ts"},
+	".mjs": {".mjs", ".d.mts"},
+	".cjs": {".cjs", ".d.cts"},
+}
+
+// projectOutputs returns the files the build of the project emits, the .tsbuildinfo file first
+func projectOutputs(project *Project) []string {
+	o := project.Options
+	var outputs []string
+	if info := buildInfoPath(project); info != "" {
+		outputs = append(outputs, info)
+	}
+	if isTrue(o.NoEmit) {
+		return outputs
+	}
+	emitJS := !isTrue(o.EmitDeclarationOnly)
+	declaration := isTrue(o.Declaration) || isTrue(o.Composite)
+	if o.OutFile != "" {
+		if emitJS {
+			outputs = append(outputs, o.OutFile)
+		}
+		if declaration {
+			outputs = append(outputs, strings.TrimSuffix(o.OutFile, path.Ext(o.OutFile))+".d.ts")
+		}
+		return outputs
+	}
+	sourceDir := commonSourceDir(project)
+	redirect := func(base string, dir string) string {
+		if dir == "" || !strings.HasPrefix(base, sourceDir+"/") {
+			return base
+		}
+		return path.Join(dir, strings.TrimPrefix(base, sourceDir))
+	}
+	for _, name := range project.FileNames {
+		if _, ext := splitExtension(name, dtsExtensions); ext != "" {
+			continue
+		}
+		base, ext := splitExtension(name, append(append([]string{}, tsExtensions...), jsExtensions...))
+		exts, ok := outputExtensions[ext]
+		if !ok {
+			continue
+		}
+		if ext == ".tsx" || ext == ".jsx" {
+			if o.Jsx != nil && (*o.Jsx == JsxEmitPreserve || *o.Jsx == JsxEmitReactNative) {
+				exts[0] = ".jsx"
+			}
+		}
+		_, isJS := splitExtension(name, jsExtensions)
+		// the javascript inputs are only emitted to another directory, they would be overwritten
+		if emitJS && (isJS == "" || o.OutDir != "") {
+			outputs = append(outputs, redirect(base, o.OutDir)+exts[0])
+		}
+		if declaration {
+			dir := o.DeclarationDir
+			if dir == "" {
+				dir = o.OutDir
+			}
+			outputs = append(outputs, redirect(base, dir)+exts[1])
+		}
+	}
+	return outputs
+}
+
+func (b *solutionBuilder) modTime(filePath string) (time.Time, bool, error) {
+	info, err := b.fs.Stat(filePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return time.Time{}, false, nil
+	} else if err != nil {
+		return time.Time{}, false, fmt.Errorf("unable to check the file \"%s\", %w", filePath, err)
+	}
+	return info.ModTime(), true, nil
+}
+
+// outputTimes returns the times of the oldest and the newest outputs, missing is the first missing output
+func (b *solutionBuilder) outputTimes(r *ProjectBuildResult) (oldest string, oldestTime time.Time, newestTime time.Time, missing string, err error) {
+	for _, output := range projectOutputs(r.Project) {
+		t, ok, err := b.modTime(output)
+		if err != nil {
+			return "", time.Time{}, time.Time{}, "", err
+		}
+		if !ok {
+			if missing == "" {
+				missing = output
+			}
+			continue
+		}
+		if oldest == "" || t.Before(oldestTime) {
+			oldest, oldestTime = output, t
+		}
+		if t.After(newestTime) {
+			newestTime = t
+		}
+	}
+	return oldest, oldestTime, newestTime, missing, nil
+}
+
+// upToDate compares the outputs of the project with its inputs and the outputs of its references,
+// the reason tells which file makes the decision
+func (b *solutionBuilder) upToDate(r *ProjectBuildResult) (bool, string, error) {
+	oldest, oldestTime, newestTime, missing, err := b.outputTimes(r)
+	if err != nil {
+		return false, "", err
+	}
+	r.newestOutput = newestTime
+	if missing != "" {
+		return false, fmt.Sprintf("output file '%s' does not exist", missing), nil
+	}
+	// without outputs nothing tells when the project was checked
+	if oldest == "" {
+		return false, "the project has no outputs", nil
+	}
+	inputs := append([]string{r.ConfigPath}, r.Project.FileNames...)
+	for _, input := range inputs {
+		t, ok, err := b.modTime(input)
+		if err != nil {
+			return false, "", err
+		}
+		if !ok {
+			return false, fmt.Sprintf("input file '%s' does not exist", input), nil
+		}
+		if t.After(oldestTime) {
+			return false, fmt.Sprintf("output '%s' is older than input '%s'", oldest, input), nil
+		}
+	}
+	for _, upstream := range r.upstream {
+		if upstream.newestOutput.After(oldestTime) {
+			return false, fmt.Sprintf("output '%s' is older than the outputs of the referenced project '%s'", oldest, upstream.ConfigPath), nil
+		}
+	}
+	return true, fmt.Sprintf("the oldest output '%s' is newer than the inputs", oldest), nil
+}
+
+func (b *solutionBuilder) build(r *ProjectBuildResult) error {
+	project := r.Project
+	options := project.Options
+	// tsc -b acts as if noEmitOnError is set, so the failed projects are not seen up to date by the next build
+	options.NoEmitOnError = Ptr(true)
+	options.TsBuildInfoFile = buildInfoPath(project)
+	if b.compiler == nil {
+		c, err := NewCompiler(b.fs, b.opts.CompilerOptions...)
+		if err != nil {
+			return fmt.Errorf("unable to build the project \"%s\", %w", r.ConfigPath, err)
+		}
+		b.compiler = c
+	}
+	builder, err := b.compiler.newBuilder(project.FileNames, options.ToAPI(), project.References)
+	if err != nil {
+		return fmt.Errorf("unable to build the project \"%s\", %w", r.ConfigPath, err)
+	}
+	res, err := builder.Build(nil)
+	if err != nil {
+		return fmt.Errorf("unable to build the project \"%s\", %w", r.ConfigPath, err)
+	}
+	r.EmittedFiles = res.EmittedFiles
+	r.Diagnostics = append(r.Diagnostics, res.Diagnostics...)
+	if hasErrors(res.Diagnostics) {
+		r.Status = ProjectBuildErrors
+		return nil
+	}
+	r.Status = ProjectBuilt
+	err = b.touchOutputs(r)
+	if err != nil {
+		return err
+	}
+	_, _, r.newestOutput, _, err = b.outputTimes(r)
+	return err
+}
+
+// touchOutputs updates the modification times of the outputs the build didn't emit, as tsc -b does,
+// because the builder only emits the outputs of the changed files and the older ones would keep
+// the project out of date
+func (b *solutionBuilder) touchOutputs(r *ProjectBuildResult) error {
+	now := time.Now()
+	for _, output := range projectOutputs(r.Project) {
+		if indexOf(r.EmittedFiles, output) >= 0 {
+			continue
+		}
+		_, ok, err := b.modTime(output)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		err = filesystem.SetModTime(b.fs, output, now)
+		if err != nil {
+			return fmt.Errorf("unable to update the modification time of the output \"%s\", %w", output, err)
+		}
+	}
+	return nil
+}
+
+func (b *solutionBuilder) buildProject(r *ProjectBuildResult) error {
+	if r.Project == nil || hasErrors(r.Diagnostics) {
+		r.Status = ProjectConfigErrors
+		r.Reason = "the tsconfig file has errors"
+		return nil
+	}
+	for _, upstream := range r.upstream {
+		if upstream.Status.failed() {
+			r.Status = ProjectUpstreamBlocked
+			r.Reason = fmt.Sprintf("the referenced project '%s' has errors", upstream.ConfigPath)
+			return nil
+		}
+	}
+	// the solutions only list their references
+	if len(r.Project.FileNames) == 0 {
+		r.Status = ProjectUpToDate
+		r.Reason = "the project has no input files"
+		return nil
+	}
+	upToDate, reason, err := b.upToDate(r)
+	if err != nil {
+		return err
+	}
+	r.Reason = reason
+	if upToDate && !b.opts.Force {
+		r.Status = ProjectUpToDate
+		return nil
+	}
+	if b.opts.Force {
+		r.Reason = "the build is forced"
+	}
+	return b.build(r)
+}
+
+// BuildSolution builds the projects of the tsconfig files and the projects they reference, like tsc -b.
+// The referenced projects are built first, they must be composite, and the projects referencing them
+// import their declaration outputs. A project is up to date, and not built again, when its outputs are
+// newer than its tsconfig file, its inputs and the outputs of its references, so the outputs a build
+// doesn't emit again are touched, see filesystem.SetModTime. A project is not built when its tsconfig
+// file or a project it references has errors. Like tsc -b before typescript 5.6, the projects are built
+// as if noEmitOnError is set, so the failed projects are built again by the next call. The statuses and
+// the diagnostics of the projects are in the result, an error is only returned when a file can't be
+// accessed or the compiler fails.
+func BuildSolution(fileSystem filesystem.FileSystem, rootTsconfigs []string, opts *BuildOptions) (*SolutionResult, error) {
+	b := &solutionBuilder{
+		fs:      fileSystem,
+		results: map[string]*ProjectBuildResult{},
+	}
+	if opts != nil {
+		b.opts = *opts
+	}
+	defer func() {
+		if b.compiler != nil {
+			b.compiler.Close()
+		}
+	}()
+	for _, tsconfig := range rootTsconfigs {
+		configPath, err := resolveConfigPath(fileSystem, tsconfig)
+		if err != nil {
+			return nil, err
+		}
+		if _, err = b.visit(configPath); err != nil {
+			return nil, err
+		}
+	}
+	for _, r := range b.order {
+		if err := b.buildProject(r); err != nil {
+			return nil, err
+		}
+		if b.opts.OnProject != nil {
+			b.opts.OnProject(r)
+		}
+	}
+	return &SolutionResult{Projects: b.order}, nil
+}
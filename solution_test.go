@@ -0,0 +1,190 @@
+package v8tsgo
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func solutionStatuses(res *SolutionResult) string {
+	var lines []string
+	for _, p := range res.Projects {
+		lines = append(lines, p.ConfigPath+": "+p.Status.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+func TestBuildSolution(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/repo/tsconfig.json":      `{"files": [], "references": [{"path": "./app"}, {"path": "./core"}]}`,
+		"/repo/core/tsconfig.json": `{"compilerOptions": {"composite": true, "rootDir": "src", "outDir": "dist"}}`,
+		"/repo/core/src/a.ts":      "export const a: number = 1;",
+		"/repo/app/tsconfig.json":  `{"compilerOptions": {"rootDir": "src", "outDir": "dist"}, "references": [{"path": "../core"}]}`,
+		"/repo/app/src/main.ts":    "const main: number = 2;",
+	})
+	opts := &BuildOptions{CompilerOptions: []CompilerOption{WithBundle(newTestBundle(t))}}
+	var reported []string
+	opts.OnProject = func(result *ProjectBuildResult) {
+		reported = append(reported, result.ConfigPath)
+	}
+
+	res, err := BuildSolution(mfs, []string{"/repo"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/core/tsconfig.json: built",
+		"/repo/app/tsconfig.json: built",
+		"/repo/tsconfig.json: up to date",
+	}, "\n"), solutionStatuses(res), "")
+	test.AssertEqual(t, "/repo/core/tsconfig.json,/repo/app/tsconfig.json,/repo/tsconfig.json", strings.Join(reported, ","), "")
+	test.AssertEqual(t, false, res.HasErrors(), "")
+	test.AssertEqual(t, "output file '/repo/core/tsconfig.tsbuildinfo' does not exist", res.Projects[0].Reason, "")
+	test.AssertEqual(t, "/repo/core/dist/a.js,/repo/core/dist/a.d.ts,/repo/core/tsconfig.tsbuildinfo", strings.Join(res.Projects[0].EmittedFiles, ","), "")
+	test.AssertEqual(t, "/repo/app/dist/main.js", strings.Join(res.Projects[1].EmittedFiles, ","), "")
+	content, err := mfs.ReadFile("/repo/core/dist/a.d.ts", "utf-8")
+	panicIfErr(err)
+	test.AssertEqual(t, "export const a = 1;", content, "")
+
+	res, err = BuildSolution(mfs, []string{"/repo/tsconfig.json"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/core/tsconfig.json: up to date",
+		"/repo/app/tsconfig.json: up to date",
+		"/repo/tsconfig.json: up to date",
+	}, "\n"), solutionStatuses(res), "")
+	test.AssertEqual(t, 0, len(res.Projects[0].EmittedFiles), "")
+
+	// the projects referencing a rebuilt project are built again
+	panicIfErr(mfs.WriteFile("/repo/core/src/a.ts", "export const a: number = 3;"))
+	res, err = BuildSolution(mfs, []string{"/repo/app"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/core/tsconfig.json: built",
+		"/repo/app/tsconfig.json: built",
+	}, "\n"), solutionStatuses(res), "")
+	test.AssertEqual(t, "output '/repo/core/dist/a.js' is older than input '/repo/core/src/a.ts'", res.Projects[0].Reason, "")
+	test.AssertEqual(t, "output '/repo/app/dist/main.js' is older than the outputs of the referenced project '/repo/core/tsconfig.json'", res.Projects[1].Reason, "")
+
+	res, err = BuildSolution(mfs, []string{"/repo/app"}, &BuildOptions{Force: true, CompilerOptions: opts.CompilerOptions})
+	panicIfErr(err)
+	test.AssertEqual(t, "/repo/core/tsconfig.json: built\n/repo/app/tsconfig.json: built", solutionStatuses(res), "")
+}
+
+func TestBuildSolutionMultipleFiles(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/repo/core/tsconfig.json": `{"compilerOptions": {"composite": true, "rootDir": "src", "outDir": "dist"}}`,
+		"/repo/core/src/a.ts":      "export const a: number = 1;",
+		"/repo/core/src/b.ts":      "export const b: number = 2;",
+	})
+	opts := &BuildOptions{CompilerOptions: []CompilerOption{WithBundle(newTestBundle(t))}}
+	res, err := BuildSolution(mfs, []string{"/repo/core"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, "/repo/core/tsconfig.json: built", solutionStatuses(res), "")
+
+	// only the outputs of a.ts are emitted again, the ones of b.ts are touched
+	panicIfErr(mfs.WriteFile("/repo/core/src/a.ts", "export const a: number = 3;"))
+	res, err = BuildSolution(mfs, []string{"/repo/core"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, "/repo/core/tsconfig.json: built", solutionStatuses(res), "")
+	test.AssertEqual(t, "/repo/core/dist/a.js,/repo/core/dist/a.d.ts,/repo/core/tsconfig.tsbuildinfo", strings.Join(res.Projects[0].EmittedFiles, ","), "")
+
+	res, err = BuildSolution(mfs, []string{"/repo/core"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, "/repo/core/tsconfig.json: up to date", solutionStatuses(res), "")
+}
+
+func TestBuildSolutionErrors(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/repo/tsconfig.json":      `{"files": [], "references": [{"path": "./app"}]}`,
+		"/repo/core/tsconfig.json": `{"compilerOptions": {"composite": true, "rootDir": "src", "outDir": "dist"}}`,
+		"/repo/core/src/a.ts":      "export const a: number = 1; // @error broken",
+		"/repo/app/tsconfig.json":  `{"compilerOptions": {"rootDir": "src", "outDir": "dist"}, "references": [{"path": "../core"}]}`,
+		"/repo/app/src/main.ts":    "const main: number = 2;",
+	})
+	opts := &BuildOptions{CompilerOptions: []CompilerOption{WithBundle(newTestBundle(t))}}
+	res, err := BuildSolution(mfs, []string{"/repo"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/core/tsconfig.json: build errors",
+		"/repo/app/tsconfig.json: upstream blocked",
+		"/repo/tsconfig.json: upstream blocked",
+	}, "\n"), solutionStatuses(res), "")
+	test.AssertEqual(t, true, res.HasErrors(), "")
+	test.AssertEqual(t, "/repo/core/src/a.ts(1,32): error TS9999: broken", diagnosticsString(res.Projects[0].Diagnostics), "")
+	test.AssertEqual(t, 0, len(res.Projects[0].EmittedFiles), "")
+	test.AssertEqual(t, "the referenced project '/repo/core/tsconfig.json' has errors", res.Projects[1].Reason, "")
+	exists, err := mfs.FileExists("/repo/core/dist/a.js")
+	panicIfErr(err)
+	test.AssertEqual(t, false, exists, "")
+
+	// nothing is emitted, so the fixed project is built again
+	panicIfErr(mfs.WriteFile("/repo/core/src/a.ts", "export const a: number = 1;"))
+	res, err = BuildSolution(mfs, []string{"/repo"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/core/tsconfig.json: built",
+		"/repo/app/tsconfig.json: built",
+		"/repo/tsconfig.json: up to date",
+	}, "\n"), solutionStatuses(res), "")
+}
+
+func TestBuildSolutionConfigErrors(t *testing.T) {
+	mfs := newProjectFS(map[string]string{
+		"/repo/a/tsconfig.json":   `{"compilerOptions": {"composite": true}, "references": [{"path": "../b"}]}`,
+		"/repo/a/a.ts":            "",
+		"/repo/b/tsconfig.json":   `{"compilerOptions": {"composite": true}, "references": [{"path": "../a"}]}`,
+		"/repo/b/b.ts":            "",
+		"/repo/c/tsconfig.json":   `{"references": [{"path": "../lib"}, {"path": "../missing"}]}`,
+		"/repo/c/c.ts":            "",
+		"/repo/lib/tsconfig.json": `{}`,
+		"/repo/lib/lib.ts":        "",
+	})
+	opts := &BuildOptions{CompilerOptions: []CompilerOption{WithBundle(newTestBundle(t))}}
+	res, err := BuildSolution(mfs, []string{"/repo/a", "/repo/c"}, opts)
+	panicIfErr(err)
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/b/tsconfig.json: config errors",
+		"/repo/a/tsconfig.json: upstream blocked",
+		"/repo/lib/tsconfig.json: built",
+		"/repo/missing/tsconfig.json: config errors",
+		"/repo/c/tsconfig.json: config errors",
+	}, "\n"), solutionStatuses(res), "")
+	test.AssertEqual(t, "", diagnosticsString(res.Projects[2].Diagnostics), "")
+	test.AssertEqual(t, strings.Join([]string{
+		"/repo/b/tsconfig.json: error TS6202: Project references may not form a circular graph. Cycle detected: /repo/a/tsconfig.json",
+		"/repo/b/tsconfig.json",
+		"/repo/a/tsconfig.json",
+	}, "\n"), diagnosticsString(res.Projects[0].Diagnostics), "")
+	test.AssertEqual(t, "/repo/missing/tsconfig.json: error TS6053: File '/repo/missing/tsconfig.json' not found.", diagnosticsString(res.Projects[3].Diagnostics), "")
+	test.AssertEqual(t, `/repo/c/tsconfig.json: error TS6306: Referenced project '/repo/lib/tsconfig.json' must have setting "composite": true.`, diagnosticsString(res.Projects[4].Diagnostics), "")
+}
+
+func TestProjectOutputs(t *testing.T) {
+	project := &Project{
+		ConfigPath: "/p/tsconfig.json",
+		FileNames:  []string{"/p/src/a.ts", "/p/src/b/c.tsx", "/p/src/d.mts", "/p/src/e.d.ts", "/p/src/f.js"},
+		Options: CompilerOptions{
+			Composite:      Ptr(true),
+			OutDir:         "/p/out",
+			DeclarationDir: "/p/types",
+			RootDir:        "/p/src",
+			Jsx:            Ptr(JsxEmitPreserve),
+		},
+	}
+	test.AssertEqual(t, strings.Join([]string{
+		"/p/tsconfig.tsbuildinfo",
+		"/p/out/a.js", "/p/types/a.d.ts",
+		"/p/out/b/c.jsx", "/p/types/b/c.d.ts",
+		"/p/out/d.mjs", "/p/types/d.d.mts",
+		"/p/out/f.js", "/p/types/f.d.ts",
+	}, ","), strings.Join(projectOutputs(project), ","), "")
+
+	project.Options = CompilerOptions{Incremental: Ptr(true), OutFile: "/p/out/bundle.js", Declaration: Ptr(true)}
+	test.AssertEqual(t, "/p/out/bundle.tsbuildinfo,/p/out/bundle.js,/p/out/bundle.d.ts", strings.Join(projectOutputs(project), ","), "")
+
+	project.Options = CompilerOptions{}
+	test.AssertEqual(t, "/p/src/a.js,/p/src/b/c.js,/p/src/d.mjs", strings.Join(projectOutputs(project), ","), "")
+
+	test.AssertEqual(t, "../x/y", relativePath("/a/b", "/a/x/y"), "")
+	test.AssertEqual(t, "b", relativePath("/", "/b"), "")
+}
@@ -0,0 +1,319 @@
+// A tiny stand-in for typescript.js used by the tests, it only implements the
+// parts of the compiler api the bootstrap script touches. Type annotations of
+// the form `: identifier` are stripped and a line containing `@error <message>`
+// produces an error diagnostic at that line. ` > ` in the message splits it into
+// a message chain and a trailing `@see <message>` adds related information.
+var ts = (function () {
+    var DiagnosticCategory = { Warning: 0, Error: 1, Suggestion: 2, Message: 3 };
+    var NewLineKind = { CarriageReturnLineFeed: 0, LineFeed: 1 };
+
+    function strip(text) {
+        return text.replace(/:\s*[A-Za-z_][A-Za-z0-9_]*/g, '');
+    }
+
+    function toMessageText(text) {
+        var parts = text.split(' > ');
+        if (parts.length === 1) {
+            return text;
+        }
+        var chain = undefined;
+        for (var i = parts.length - 1; i >= 0; i--) {
+            chain = {
+                messageText: parts[i],
+                category: DiagnosticCategory.Error,
+                code: 9999,
+                next: chain ? [chain] : undefined,
+            };
+        }
+        return chain;
+    }
+
+    function collectDiagnostics(file) {
+        var result = [];
+        var re = /@error ([^@\n]*?)\s*(?:@see ([^\n]*))?(?=\n|$)/g;
+        var m;
+        while ((m = re.exec(file.text)) !== null) {
+            var relatedInformation = undefined;
+            if (m[2] !== undefined) {
+                relatedInformation = [{
+                    file: file,
+                    start: m.index + m[0].indexOf('@see'),
+                    length: m[2].length + 5,
+                    code: 9998,
+                    category: DiagnosticCategory.Message,
+                    messageText: m[2],
+                }];
+            }
+            result.push({
+                file: file,
+                start: m.index,
+                length: m[0].length,
+                code: 9999,
+                category: DiagnosticCategory.Error,
+                messageText: toMessageText(m[1]),
+                relatedInformation: relatedInformation,
+            });
+        }
+        return result;
+    }
+
+    function getLineAndCharacterOfPosition(file, pos) {
+        var before = file.text.substring(0, pos);
+        var line = before.split('\n').length - 1;
+        return { line: line, character: pos - (before.lastIndexOf('\n') + 1) };
+    }
+
+    // like typescript, the nested messages are on their own lines indented by two spaces per level
+    function flattenDiagnosticMessageText(messageText, newLine, indent) {
+        if (typeof messageText === 'string') {
+            return messageText;
+        }
+        indent = indent || 0;
+        var result = '';
+        if (indent) {
+            result += newLine;
+            for (var i = 0; i < indent; i++) {
+                result += '  ';
+            }
+        }
+        result += messageText.messageText;
+        (messageText.next || []).forEach(function (next) {
+            result += flattenDiagnosticMessageText(next, newLine, indent + 1);
+        });
+        return result;
+    }
+
+    function createSourceFile(fileName, text) {
+        return { fileName: fileName, text: text };
+    }
+
+    // the outputs are relative to the rootDir option, or flattened in the output directory without it
+    function outputName(fileName, options, dir, ext) {
+        var name = fileName.replace(/\.ts$/, ext);
+        if (!dir) {
+            return name;
+        }
+        if (options.rootDir && name.indexOf(options.rootDir + '/') === 0) {
+            return dir + name.substring(options.rootDir.length);
+        }
+        return dir + name.substring(name.lastIndexOf('/'));
+    }
+
+    function createProgram(args) {
+        var host = args.host;
+        var options = args.options;
+        var files = [];
+        var missing = [];
+        args.rootNames.forEach(function (name) {
+            var file = host.getSourceFile(name, 99);
+            if (file) {
+                files.push(file);
+            } else {
+                missing.push(name);
+            }
+        });
+        var libName = host.getDefaultLibFileName(options);
+        var lib = options.noLib ? undefined : host.getSourceFile(libName, 99);
+        return {
+            files: files,
+            lib: lib,
+            missing: missing,
+            getCompilerOptions: function () {
+                return options;
+            },
+            // the third argument is only used by the fake builder, to emit the affected files
+            emit: function (_target, writeFile, affectedFiles) {
+                if (options.noEmit) {
+                    return { emitSkipped: true, diagnostics: [] };
+                }
+                if (options.noEmitOnError && files.some(function (file) {
+                    return collectDiagnostics(file).length > 0;
+                })) {
+                    return { emitSkipped: true, diagnostics: [] };
+                }
+                (affectedFiles || files).forEach(function (file) {
+                    if (!options.emitDeclarationOnly) {
+                        writeFile(outputName(file.fileName, options, options.outDir, '.js'), strip(file.text), false);
+                    }
+                    if (options.declaration || options.composite) {
+                        writeFile(outputName(file.fileName, options, options.declarationDir || options.outDir, '.d.ts'), strip(file.text), false);
+                    }
+                });
+                return { emitSkipped: false, diagnostics: [] };
+            },
+        };
+    }
+
+    function getOptionsDiagnostics(program) {
+        var result = [];
+        program.missing.forEach(function (name) {
+            result.push({
+                file: undefined,
+                start: undefined,
+                length: undefined,
+                code: 6053,
+                category: DiagnosticCategory.Error,
+                messageText: "File '" + name + "' not found.",
+            });
+        });
+        if (!program.getCompilerOptions().noLib && !program.lib) {
+            result.push({
+                file: undefined,
+                start: undefined,
+                length: undefined,
+                code: 6053,
+                category: DiagnosticCategory.Error,
+                messageText: 'Cannot find the default lib file.',
+            });
+        }
+        return result;
+    }
+
+    function getPreEmitDiagnostics(program) {
+        var result = getOptionsDiagnostics(program);
+        program.files.forEach(function (file) {
+            result = result.concat(collectDiagnostics(file));
+        });
+        return result;
+    }
+
+    // the builder state is the version and the diagnostics of each file, the buildinfo file
+    // is the state as json, with the diagnostics detached from their files
+    function detach(d) {
+        return Object.assign({}, d, {
+            file: undefined,
+            relatedInformation: d.relatedInformation && d.relatedInformation.map(detach),
+        });
+    }
+
+    function attach(d, file) {
+        return Object.assign({}, d, {
+            file: file,
+            relatedInformation: d.relatedInformation && d.relatedInformation.map(function (r) {
+                return attach(r, file);
+            }),
+        });
+    }
+
+    function readBuilderProgram(options, host) {
+        if (!options.tsBuildInfoFile) {
+            return undefined;
+        }
+        var text = host.readFile(options.tsBuildInfoFile);
+        if (text === undefined) {
+            return undefined;
+        }
+        return { state: JSON.parse(text) };
+    }
+
+    // only the changed files are affected, the fake has no dependency graph
+    function createEmitAndSemanticDiagnosticsBuilderProgram(rootNames, options, host, oldProgram) {
+        var program = createProgram({ rootNames: rootNames, options: options, host: host });
+        var old = oldProgram ? oldProgram.state : { versions: {}, diagnostics: {} };
+        var state = { versions: {}, diagnostics: {} };
+        var pendingCheck = [];
+        var pendingEmit = [];
+        program.files.forEach(function (file) {
+            var version = file.version !== undefined ? file.version : file.text;
+            state.versions[file.fileName] = version;
+            if (old.versions[file.fileName] === version && old.diagnostics[file.fileName]) {
+                state.diagnostics[file.fileName] = old.diagnostics[file.fileName];
+            } else {
+                pendingCheck.push(file);
+                pendingEmit.push(file);
+            }
+        });
+
+        function check(file) {
+            state.diagnostics[file.fileName] = collectDiagnostics(file).map(detach);
+            pendingCheck = pendingCheck.filter(function (f) {
+                return f !== file;
+            });
+        }
+
+        function semanticDiagnostics(file) {
+            if (!state.diagnostics[file.fileName]) {
+                check(file);
+            }
+            return state.diagnostics[file.fileName].map(function (d) {
+                return attach(d, file);
+            });
+        }
+
+        function none() {
+            return [];
+        }
+
+        return {
+            state: state,
+            getProgram: function () {
+                return program;
+            },
+            getConfigFileParsingDiagnostics: none,
+            getOptionsDiagnostics: function () {
+                return getOptionsDiagnostics(program);
+            },
+            getGlobalDiagnostics: none,
+            getSyntacticDiagnostics: none,
+            getSemanticDiagnostics: function () {
+                var result = [];
+                program.files.forEach(function (file) {
+                    result = result.concat(semanticDiagnostics(file));
+                });
+                return result;
+            },
+            getSemanticDiagnosticsOfNextAffectedFile: function () {
+                var file = pendingCheck[0];
+                if (!file) {
+                    return undefined;
+                }
+                return { result: semanticDiagnostics(file), affected: file };
+            },
+            emit: function (_target, writeFile) {
+                var result = { emitSkipped: false, diagnostics: [] };
+                if (pendingEmit.length > 0) {
+                    result = program.emit(undefined, writeFile, pendingEmit);
+                }
+                // the skipped files stay pending, and the state is not saved until they are emitted
+                if (result.emitSkipped && !options.noEmit) {
+                    return result;
+                }
+                pendingEmit = [];
+                if (options.tsBuildInfoFile) {
+                    writeFile(options.tsBuildInfoFile, JSON.stringify(state), false);
+                }
+                return result;
+            },
+        };
+    }
+
+    function transpileModule(input, transpileOptions) {
+        var file = createSourceFile(transpileOptions.fileName || 'module.ts', input);
+        var outputText = strip(input);
+        if (transpileOptions.moduleName) {
+            outputText = '// module: ' + transpileOptions.moduleName + '\n' + outputText;
+        }
+        return {
+            outputText: outputText,
+            sourceMapText: transpileOptions.compilerOptions && transpileOptions.compilerOptions.sourceMap ? '{"version":3}' : undefined,
+            diagnostics: collectDiagnostics(file),
+        };
+    }
+
+    return {
+        version: '0.0.0-fake',
+        DiagnosticCategory: DiagnosticCategory,
+        NewLineKind: NewLineKind,
+        flattenDiagnosticMessageText: flattenDiagnosticMessageText,
+        createSourceFile: createSourceFile,
+        createProgram: createProgram,
+        getPreEmitDiagnostics: getPreEmitDiagnostics,
+        readBuilderProgram: readBuilderProgram,
+        createEmitAndSemanticDiagnosticsBuilderProgram: createEmitAndSemanticDiagnosticsBuilderProgram,
+        getLineAndCharacterOfPosition: getLineAndCharacterOfPosition,
+        getDefaultLibFileName: function () {
+            return 'lib.d.ts';
+        },
+        transpileModule: transpileModule,
+    };
+})();
@@ -0,0 +1,141 @@
+package v8tsgo
+
+import (
+	"fmt"
+	"sync"
+
+	v8 "rogchap.com/v8go"
+)
+
+// TranspileOptions mirrors ts.TranspileOptions.
+type TranspileOptions struct {
+	// FileName is used in the diagnostics and the source map, its extension decides whether jsx is allowed.
+	// Defaults to "module.ts".
+	FileName            string
+	CompilerOptions     map[string]any
+	ModuleName          string
+	RenamedDependencies map[string]string
+}
+
+type TranspileOutput struct {
+	OutputText string `json:"outputText"`
+	// SourceMapText is empty unless the sourceMap compiler option is set.
+	SourceMapText string `json:"sourceMapText"`
+	// Diagnostics only contains the syntactic diagnostics.
+	Diagnostics []Diagnostic `json:"diagnostics"`
+}
+
+func decodeTranspileOutput(value *v8.Value) (TranspileOutput, error) {
+	var out TranspileOutput
+	obj, err := value.AsObject()
+	if err != nil {
+		return out, err
+	}
+	if out.OutputText, err = objectGetString(obj, "outputText"); err != nil {
+		return out, err
+	}
+	if out.SourceMapText, err = objectGetString(obj, "sourceMapText"); err != nil {
+		return out, err
+	}
+	if out.Diagnostics, err = decodeObjectDiagnostics(obj); err != nil {
+		return out, err
+	}
+	return out, nil
+}
+
+func makeTranspileOptions(ctx *v8.Context, opts TranspileOptions) (*v8.Value, error) {
+	fileName := opts.FileName
+	if fileName == "" {
+		fileName = "module.ts"
+	}
+	compilerOptions := opts.CompilerOptions
+	if compilerOptions == nil {
+		compilerOptions = map[string]any{}
+	}
+	options := map[string]any{
+		"fileName":        fileName,
+		"compilerOptions": compilerOptions,
+	}
+	if opts.ModuleName != "" {
+		options["moduleName"] = opts.ModuleName
+	}
+	if opts.RenamedDependencies != nil {
+		options["renamedDependencies"] = opts.RenamedDependencies
+	}
+	return MakeValue(ctx, options)
+}
+
+func transpileModule(ctx *v8.Context, api *v8.Object, source string, opts TranspileOptions) (TranspileOutput, error) {
+	valSource, err := v8.NewValue(ctx.Isolate(), source)
+	if err != nil {
+		return TranspileOutput{}, err
+	}
+	valOptions, err := makeTranspileOptions(ctx, opts)
+	if err != nil {
+		return TranspileOutput{}, fmt.Errorf("unable to make the transpile options value, %w", err)
+	}
+	res, err := api.MethodCall("transpile", valSource, valOptions)
+	if err != nil {
+		return TranspileOutput{}, AsJSException(err)
+	}
+	out, err := decodeTranspileOutput(res)
+	if err != nil {
+		return TranspileOutput{}, fmt.Errorf("unable to parse the transpile output, %w", err)
+	}
+	return out, nil
+}
+
+// Transpiler only loads the typescript compiler, it has no file system host and never type checks.
+// A Transpiler is not safe for concurrent use.
+type Transpiler struct {
+	ctx *v8.Context
+	api *v8.Object
+}
+
+func NewTranspiler(opts ...CompilerOption) (*Transpiler, error) {
+	cfg := newCompilerConfig(opts)
+	ctx := v8.NewContext()
+	api, err := loadBundle(ctx, cfg)
+	if err != nil {
+		closeContext(ctx)
+		return nil, err
+	}
+	return &Transpiler{
+		ctx: ctx,
+		api: api,
+	}, nil
+}
+
+// TranspileModule calls ts.transpileModule on the source.
+func (t *Transpiler) TranspileModule(source string, opts TranspileOptions) (TranspileOutput, error) {
+	out, err := transpileModule(t.ctx, t.api, source, opts)
+	if err != nil {
+		return out, fmt.Errorf("unable to transpile the module, %w", err)
+	}
+	return out, nil
+}
+
+// Close disposes the underlying isolate, the transpiler is unusable after this call.
+func (t *Transpiler) Close() {
+	closeContext(t.ctx)
+}
+
+var defaultTranspiler struct {
+	mu         sync.Mutex
+	transpiler *Transpiler
+}
+
+// TranspileModule transpiles the source with a shared Transpiler created on the first call.
+// The calls are serialized, use a Transpiler per goroutine to transpile in parallel.
+func TranspileModule(source string, opts TranspileOptions) (TranspileOutput, error) {
+	defaultTranspiler.mu.Lock()
+	defer defaultTranspiler.mu.Unlock()
+	if defaultTranspiler.transpiler == nil {
+		t, err := NewTranspiler()
+		if err != nil {
+			return TranspileOutput{}, err
+		}
+		defaultTranspiler.transpiler = t
+	}
+	return defaultTranspiler.transpiler.TranspileModule(source, opts)
+}
@@ -0,0 +1,44 @@
+package v8tsgo
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+func TestTranspilerTranspileModule(t *testing.T) {
+	tr, err := NewTranspiler(WithBundle(newTestBundle(t)))
+	panicIfErr(err)
+	defer tr.Close()
+
+	out, err := tr.TranspileModule("let a: number = 1;", TranspileOptions{})
+	panicIfErr(err)
+	test.AssertEqual(t, "let a = 1;", out.OutputText, "")
+	test.AssertEqual(t, "", out.SourceMapText, "")
+	test.AssertEqual(t, 0, len(out.Diagnostics), "")
+
+	out, err = tr.TranspileModule("let a: number = 1;", TranspileOptions{
+		FileName:        "/scripts/a.ts",
+		ModuleName:      "a",
+		CompilerOptions: map[string]any{"sourceMap": true},
+	})
+	panicIfErr(err)
+	test.AssertEqual(t, "// module: a\nlet a = 1;", out.OutputText, "")
+	test.AssertEqual(t, `{"version":3}`, out.SourceMapText, "")
+
+	out, err = tr.TranspileModule("// @error unexpected token", TranspileOptions{FileName: "/scripts/b.ts"})
+	panicIfErr(err)
+	test.MustEqual(t, 1, len(out.Diagnostics), "")
+	test.AssertEqual(t, "/scripts/b.ts", out.Diagnostics[0].File, "")
+	test.AssertEqual(t, "unexpected token", out.Diagnostics[0].Message, "")
+}
+
+func TestTranspileModule(t *testing.T) {
+	out, err := TranspileModule("let a: number = 1;", TranspileOptions{})
+	if errors.Is(err, ErrBundleNotBuilt) {
+		t.Skip(err)
+	}
+	panicIfErr(err)
+	test.AssertEqual(t, "let a = 1;\n", out.OutputText, "")
+}
@@ -1 +1,283 @@
-package v8tsgo
\ No newline at end of file
+package v8tsgo
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	v8 "rogchap.com/v8go"
+)
+
+type EmitResult struct {
+	EmitSkipped  bool         `json:"emitSkipped"`
+	EmittedFiles []string     `json:"emittedFiles"`
+	Diagnostics  []Diagnostic `json:"diagnostics"`
+}
+
+func decodeEmitResult(value *v8.Value) (*EmitResult, error) {
+	obj, err := value.AsObject()
+	if err != nil {
+		return nil, err
+	}
+	var out EmitResult
+	if out.EmitSkipped, err = objectGetBool(obj, "emitSkipped"); err != nil {
+		return nil, err
+	}
+	valFiles, err := objectGet(obj, "emittedFiles")
+	if err != nil {
+		return nil, err
+	}
+	files, err := arrayElements(valFiles)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the emitted files, %w", err)
+	}
+	for _, file := range files {
+		out.EmittedFiles = append(out.EmittedFiles, file.String())
+	}
+	if out.Diagnostics, err = decodeObjectDiagnostics(obj); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+type compilerConfig struct {
+	bundle    fs.FS
+	codeCache *CodeCache
+}
+
+type CompilerOption func(cfg *compilerConfig)
+
+// WithBundle replaces the embedded bundle. The bundle must have the same layout as js/dist.
+func WithBundle(bundle fs.FS) CompilerOption {
+	return func(cfg *compilerConfig) {
+		cfg.bundle = bundle
+	}
+}
+
+// Compiler runs the typescript compiler in its own v8 isolate,
+// all the files are read from and written to the file system passed to NewCompiler.
+// A Compiler is not safe for concurrent use.
+type Compiler struct {
+	ctx    *v8.Context
+	rt     *Runtime
+	fsHost *V8FileSystemHost
+	host   *v8.Value
+	bundle *v8.Value
+	api    *v8.Object
+	fs     filesystem.FileSystem
+}
+
+func newBundleObject(ctx *v8.Context, bundle fs.FS) (*v8.Value, error) {
+	iso := ctx.Isolate()
+	fnReadLib := v8.NewFunctionTemplate(iso, func(info *v8.FunctionCallbackInfo) *v8.Value {
+		name, err := extractStringArg(info, 0)
+		if err != nil {
+			return v8.Undefined(iso)
+		}
+		content, ok := readBundleLib(bundle, name)
+		if !ok {
+			return v8.Undefined(iso)
+		}
+		return mustNewValue(iso, content)
+	})
+	t := v8.NewObjectTemplate(iso)
+	err := t.Set("libDir", bundleLibMount, v8.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set property \"libDir\" on the bundle object, %w", err)
+	}
+	err = t.Set("readLib", fnReadLib, v8.ReadOnly)
+	if err != nil {
+		return nil, fmt.Errorf("failed to set method \"readLib\" on the bundle object, %w", err)
+	}
+	obj, err := t.NewInstance(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to create the bundle object, %w", err)
+	}
+	return obj.Value, nil
+}
+
+// loadBundle evaluates the typescript compiler, the bootstrap script and the go utils in the context,
+// and returns the object exposed by the bootstrap script.
+func loadBundle(ctx *v8.Context, cfg *compilerConfig) (*v8.Object, error) {
+	scripts, err := readBundleScripts(cfg.bundle)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.codeCache != nil {
+		err = cfg.codeCache.run(ctx, scripts)
+	} else {
+		for _, script := range scripts {
+			_, err = ctx.RunScript(script.source, script.name)
+			if err != nil {
+				err = fmt.Errorf("failed to execute %s, %w", script.name, err)
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	valApi, err := ctx.Global().Get("v8tsgo")
+	if err != nil {
+		return nil, fmt.Errorf("unable to access the v8tsgo value, %w", err)
+	}
+	api, err := valApi.AsObject()
+	if err != nil {
+		return nil, fmt.Errorf("unable to cast the v8tsgo value to an object, %w", err)
+	}
+	return api, nil
+}
+
+func newCompilerConfig(opts []CompilerOption) *compilerConfig {
+	cfg := &compilerConfig{}
+	for _, opt := range opts {
+		opt(cfg)
+	}
+	if cfg.bundle == nil {
+		cfg.bundle = DefaultBundle()
+	}
+	return cfg
+}
+
+func NewCompiler(fileSystem filesystem.FileSystem, opts ...CompilerOption) (*Compiler, error) {
+	cfg := newCompilerConfig(opts)
+	ctx := v8.NewContext()
+	c, err := initCompiler(ctx, fileSystem, cfg)
+	if err != nil {
+		closeContext(ctx)
+		return nil, err
+	}
+	return c, nil
+}
+
+func initCompiler(ctx *v8.Context, fileSystem filesystem.FileSystem, cfg *compilerConfig) (*Compiler, error) {
+	api, err := loadBundle(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+	rt, err := NewRuntime(ctx)
+	if err != nil {
+		return nil, err
+	}
+	fsHost := NewV8FileSystem(fileSystem, rt.utils)
+	host, err := fsHost.CreateInstance()
+	if err != nil {
+		return nil, err
+	}
+	bundleObj, err := newBundleObject(ctx, cfg.bundle)
+	if err != nil {
+		return nil, err
+	}
+	return &Compiler{
+		ctx:    ctx,
+		rt:     rt,
+		fsHost: fsHost,
+		host:   host,
+		bundle: bundleObj,
+		api:    api,
+		fs:     fileSystem,
+	}, nil
+}
+
+func closeContext(ctx *v8.Context) {
+	iso := ctx.Isolate()
+	ctx.Close()
+	iso.Dispose()
+}
+
+// Runtime returns the runtime the compiler runs in.
+func (c *Compiler) Runtime() *Runtime {
+	return c.rt
+}
+
+// Version returns the version of the loaded typescript compiler.
+func (c *Compiler) Version() (string, error) {
+	v, err := c.api.Get("version")
+	if err != nil {
+		return "", fmt.Errorf("unable to access the typescript version, %w", err)
+	}
+	return v.String(), nil
+}
+
+func (c *Compiler) makeArgs(rootNames []string, options map[string]any) (*v8.Value, *v8.Value, error) {
+	if rootNames == nil {
+		rootNames = []string{}
+	}
+	valRootNames, err := MakeValue(c.ctx, rootNames)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to make the root names value, %w", err)
+	}
+	valOptions, err := c.makeOptions(options)
+	if err != nil {
+		return nil, nil, err
+	}
+	return valRootNames, valOptions, nil
+}
+
+func (c *Compiler) makeOptions(options map[string]any) (*v8.Value, error) {
+	if options == nil {
+		options = map[string]any{}
+	}
+	valOptions, err := MakeValue(c.ctx, options)
+	if err != nil {
+		return nil, fmt.Errorf("unable to make the compiler options value, %w", err)
+	}
+	return valOptions, nil
+}
+
+// Transpile reads the file from the file system and transpiles it without type checking.
+func (c *Compiler) Transpile(fileName string, options map[string]any) (TranspileOutput, error) {
+	source, err := c.fs.ReadFile(fileName, "utf-8")
+	if err != nil {
+		return TranspileOutput{}, fmt.Errorf("unable to transpile \"%s\", %w", fileName, err)
+	}
+	out, err := transpileModule(c.ctx, c.api, source, TranspileOptions{
+		FileName:        fileName,
+		CompilerOptions: options,
+	})
+	if err != nil {
+		return TranspileOutput{}, fmt.Errorf("unable to transpile \"%s\", %w", fileName, err)
+	}
+	return out, nil
+}
+
+// TypeCheck creates a program from the root files and returns its pre-emit diagnostics.
+func (c *Compiler) TypeCheck(rootNames []string, options map[string]any) ([]Diagnostic, error) {
+	valRootNames, valOptions, err := c.makeArgs(rootNames, options)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.api.MethodCall("typeCheck", c.host, c.bundle, valRootNames, valOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to type check, %w", AsJSException(err))
+	}
+	diagnostics, err := decodeDiagnostics(res)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the diagnostics, %w", err)
+	}
+	return diagnostics, nil
+}
+
+// Emit creates a program from the root files and writes the outputs to the file system.
+func (c *Compiler) Emit(rootNames []string, options map[string]any) (*EmitResult, error) {
+	valRootNames, valOptions, err := c.makeArgs(rootNames, options)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.api.MethodCall("emit", c.host, c.bundle, valRootNames, valOptions)
+	if err != nil {
+		return nil, fmt.Errorf("unable to emit, %w", AsJSException(err))
+	}
+	out, err := decodeEmitResult(res)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse the emit result, %w", err)
+	}
+	return out, nil
+}
+
+// Close stops the watchers of the compiler and disposes the underlying isolate, the compiler is unusable after this call.
+func (c *Compiler) Close() {
+	c.fsHost.Close()
+	c.rt.Loop().Close()
+	closeContext(c.ctx)
+}
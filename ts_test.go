@@ -1,3 +1,161 @@
 package v8tsgo
 
-import _ "rogchap.com/v8go"
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"github.com/vipcxj/v8tsgo/filesystem"
+	"github.com/vipcxj/v8tsgo/internal/test"
+)
+
+// newTestBundle combines the real bootstrap script with a fake typescript.js,
+// so the tests don't depend on the generated bundle.
+func newTestBundle(t *testing.T) fs.FS {
+	t.Helper()
+	tsSource, err := os.ReadFile("testdata/fake_typescript.js")
+	panicIfErr(err)
+	bootstrap, err := fs.ReadFile(DefaultBundle(), bundleBootstrap)
+	panicIfErr(err)
+	return fstest.MapFS{
+		bundleTypescript: {Data: tsSource},
+		bundleBootstrap:  {Data: bootstrap},
+		"lib/lib.d.ts":   {Data: []byte("interface Array<T> {}")},
+	}
+}
+
+func newTestCompiler(t *testing.T, files map[string]string) (*Compiler, *filesystem.MemoryFS) {
+	t.Helper()
+	mfs := filesystem.NewMemoryFS(true)
+	for path, content := range files {
+		panicIfErr(mfs.Mkdir(path[0 : strings.LastIndex(path, "/")+1]))
+		panicIfErr(mfs.WriteFile(path, content))
+	}
+	c, err := NewCompiler(mfs, WithBundle(newTestBundle(t)))
+	panicIfErr(err)
+	t.Cleanup(c.Close)
+	return c, mfs
+}
+
+func TestNewCompilerWithoutBundle(t *testing.T) {
+	_, err := NewCompiler(filesystem.NewMemoryFS(true), WithBundle(fstest.MapFS{}))
+	test.MustEqual(t, true, errors.Is(err, ErrBundleNotBuilt), "")
+}
+
+func TestCompilerVersion(t *testing.T) {
+	c, _ := newTestCompiler(t, nil)
+	v, err := c.Version()
+	panicIfErr(err)
+	test.AssertEqual(t, "0.0.0-fake", v, "")
+}
+
+func TestCompilerTranspile(t *testing.T) {
+	c, _ := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "const a: number = 1;",
+	})
+	out, err := c.Transpile("/src/a.ts", map[string]any{"sourceMap": true})
+	panicIfErr(err)
+	test.AssertEqual(t, "const a = 1;", out.OutputText, "")
+	test.AssertEqual(t, `{"version":3}`, out.SourceMapText, "")
+	test.AssertEqual(t, 0, len(out.Diagnostics), "")
+
+	_, err = c.Transpile("/src/missing.ts", nil)
+	test.AssertEqual(t, true, err != nil, "")
+}
+
+func TestCompilerTypeCheck(t *testing.T) {
+	c, _ := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "const a: number = 1;",
+		"/src/b.ts": "// @error bad thing",
+	})
+	diagnostics, err := c.TypeCheck([]string{"/src/a.ts"}, nil)
+	panicIfErr(err)
+	test.AssertEqual(t, 0, len(diagnostics), "")
+
+	diagnostics, err = c.TypeCheck([]string{"/src/a.ts", "/src/b.ts", "/src/c.ts"}, nil)
+	panicIfErr(err)
+	test.MustEqual(t, 2, len(diagnostics), "")
+	test.AssertEqual(t, 6053, diagnostics[0].Code, "")
+	test.AssertEqual(t, "", diagnostics[0].File, "")
+	test.AssertEqual(t, "/src/b.ts", diagnostics[1].File, "")
+	test.AssertEqual(t, DiagnosticCategoryError, diagnostics[1].Category, "")
+	test.AssertEqual(t, "bad thing", diagnostics[1].Message, "")
+	test.AssertEqual(t, 3, diagnostics[1].Start, "")
+}
+
+func TestCompilerIOFS(t *testing.T) {
+	iofs := filesystem.NewIOFS(fstest.MapFS{
+		"src/a.ts":     {Data: []byte("export const a: number = 1;")},
+		"src/lib/b.ts": {Data: []byte("// @error bad thing")},
+	})
+	c, err := NewCompiler(iofs, WithBundle(newTestBundle(t)))
+	panicIfErr(err)
+	defer c.Close()
+	diagnostics, err := c.TypeCheck([]string{"/src/a.ts", "/src/lib/b.ts"}, nil)
+	panicIfErr(err)
+	test.MustEqual(t, 1, len(diagnostics), "")
+	test.AssertEqual(t, "/src/lib/b.ts", diagnostics[0].File, "")
+}
+
+func TestCompilerEmit(t *testing.T) {
+	c, mfs := newTestCompiler(t, map[string]string{
+		"/src/a.ts": "export const a: number = 1;",
+	})
+	res, err := c.Emit([]string{"/src/a.ts"}, map[string]any{"outDir": "/dist"})
+	panicIfErr(err)
+	test.AssertEqual(t, false, res.EmitSkipped, "")
+	test.MustEqual(t, 1, len(res.EmittedFiles), "")
+	test.AssertEqual(t, "/dist/a.js", res.EmittedFiles[0], "")
+	content, err := mfs.ReadFile("/dist/a.js", "utf-8")
+	panicIfErr(err)
+	test.AssertEqual(t, "export const a = 1;", content, "")
+}
+
+// newRealBundle returns the generated bundle, the one of the directory in V8TSGO_BUNDLE or the embedded one.
+// The test is skipped when the embedded typescript.js is not built, and fails when the given bundle misses it,
+// as in the CI which builds it.
+func newRealBundle(t *testing.T) fs.FS {
+	t.Helper()
+	dir := os.Getenv("V8TSGO_BUNDLE")
+	if dir == "" {
+		bundle := DefaultBundle()
+		if _, err := fs.Stat(bundle, bundleTypescript); err != nil {
+			t.Skipf("%v, missing %s", ErrBundleNotBuilt, bundleTypescript)
+		}
+		return bundle
+	}
+	bundle := os.DirFS(dir)
+	if _, err := fs.Stat(bundle, bundleTypescript); err != nil {
+		t.Fatalf("%v, missing %s in %s", ErrBundleNotBuilt, bundleTypescript, dir)
+	}
+	return bundle
+}
+
+func TestCompilerRealTypescript(t *testing.T) {
+	mfs := filesystem.NewMemoryFS(true)
+	panicIfErr(mfs.Mkdir("/src"))
+	panicIfErr(mfs.WriteFile("/src/a.ts", "export const a: number = 1;\n"))
+	panicIfErr(mfs.WriteFile("/src/b.ts", "export const b: number = \"b\";\n"))
+	c, err := NewCompiler(mfs, WithBundle(newRealBundle(t)))
+	panicIfErr(err)
+	defer c.Close()
+
+	version, err := c.Version()
+	panicIfErr(err)
+	test.AssertEqual(t, false, strings.Contains(version, "fake"), "")
+
+	diagnostics, err := c.TypeCheck([]string{"/src/a.ts", "/src/b.ts"}, map[string]any{"strict": true})
+	panicIfErr(err)
+	test.MustEqual(t, 1, len(diagnostics), "")
+	test.AssertEqual(t, "/src/b.ts(1,14): error TS2322: Type 'string' is not assignable to type 'number'.", diagnostics[0].String(), "")
+
+	res, err := c.Emit([]string{"/src/a.ts"}, map[string]any{"outDir": "/dist", "target": 99, "module": 99})
+	panicIfErr(err)
+	test.AssertEqual(t, 0, len(res.Diagnostics), "")
+	content, err := mfs.ReadFile("/dist/a.js", "utf-8")
+	panicIfErr(err)
+	test.AssertEqual(t, "export const a = 1;\n", content, "")
+}
@@ -17,7 +17,14 @@ func panicIfErr(err error) {
 	}
 }
 
+// MakeValue converts the go value to a v8 value. Structs, maps, slices and arrays become objects and arrays
+// whose fields and elements follow the same rules, the struct fields are named and filtered by their json tags.
+// An int64 or uint64 is a bigint at the top level, but a number in them if it is a safe integer, as in json.
 func MakeValue(ctx *v8go.Context, goVal any) (*v8go.Value, error) {
+	return makeValue(ctx, goVal, 0)
+}
+
+func makeValue(ctx *v8go.Context, goVal any, depth int) (*v8go.Value, error) {
 	iso := ctx.Isolate()
 	if goVal == nil {
 		return v8go.Null(iso), nil
@@ -137,6 +144,9 @@ func MakeValue(ctx *v8go.Context, goVal any) (*v8go.Value, error) {
 		}
 		return v8go.NewValue(iso, v)
 	case *time.Time:
+		if v == nil {
+			return v8go.Null(iso), nil
+		}
 		return ctx.RunScript(fmt.Sprintf("new Date(%d)", v.UnixMilli()), fmt.Sprintf("create-date-%d.js", v.UnixMilli()))
 	case error:
 		errStr := v.Error()
@@ -153,14 +163,12 @@ func MakeValue(ctx *v8go.Context, goVal any) (*v8go.Value, error) {
 		switch rv.Kind() {
 		case reflect.Struct:
 			fallthrough
+		case reflect.Map:
+			fallthrough
 		case reflect.Slice:
 			fallthrough
 		case reflect.Array:
-			j, err := json.Marshal(v)
-			if err != nil {
-				return nil, fmt.Errorf("unable to marshal %v to json when making a v8 value, %w", v, err)
-			}
-			return v8go.JSONParse(ctx, string(j))
+			return makeReflectValue(ctx, rv, depth)
 		case reflect.Uint8:
 			fallthrough
 		case reflect.Uint16:
@@ -169,12 +177,19 @@ func MakeValue(ctx *v8go.Context, goVal any) (*v8go.Value, error) {
 			return v8go.NewValue(iso, uint32(rv.Uint()))
 		case reflect.Uint64:
 			return v8go.NewValue(iso, rv.Uint())
+		case reflect.Uint:
+			v := rv.Uint()
+			if v <= math.MaxUint32 {
+				return v8go.NewValue(iso, uint32(v))
+			} else {
+				return v8go.NewValue(iso, v)
+			}
 		case reflect.Int8:
 			fallthrough
 		case reflect.Int16:
 			fallthrough
 		case reflect.Int32:
-			return v8go.NewValue(iso, int32(rv.Uint()))
+			return v8go.NewValue(iso, int32(rv.Int()))
 		case reflect.Int64:
 			return v8go.NewValue(iso, rv.Int())
 		case reflect.Int:
@@ -210,39 +225,91 @@ func dateGetTime(value *v8go.Value) (int64, error) {
 	return tsValue.Integer(), nil
 }
 
+// ParseValue decodes the value into out, which must be a non-nil pointer. The objects are walked directly
+// into structs, whose fields are named by their json tags and match the keys case-insensitively as in
+// encoding/json, and maps, arrays, sets and typed arrays into slices and arrays, and dates into time.Time
+// at any depth. The integers accept bigints and the numbers
+// which are safe integers, *big.Int accepts any integer. A value which does not fit is reported
+// as a *DecodeError locating it, such as `field "a.b[2]": expected number`, as is a value nested
+// deeper than the values MakeValue accepts, which catches the cycles.
 func ParseValue(ctx *v8go.Context, value *v8go.Value, out any) error {
 	if value.IsNull() || value.IsUndefined() {
 		return nil
 	}
-	switch o := out.(type) {
-	case *bool:
-		if !value.IsBoolean() {
-			return fmt.Errorf("the input value is not a boolean value")
-		}
-		*o = value.Boolean()
-	case *string:
-		if !value.IsString() && !value.IsStringObject() {
-			return fmt.Errorf("the input value is not a string value")
-		}
-		*o = value.String()
-	case *time.Time:
-		if !value.IsDate() {
-			return fmt.Errorf("the input value is not a date value")
-		}
-		ts, err := dateGetTime(value)
-		if err != nil {
-			return fmt.Errorf("failed to get the timestamp from the v8 date value, %w", err)
-		}
-		*o = time.UnixMilli(ts)
-	default:
-		js, err := v8go.JSONStringify(ctx, value)
-		if err != nil {
-			return fmt.Errorf("unable to json stringify the v8 value, %w", err)
-		}
-		err = json.Unmarshal([]byte(js), out)
+	rv := reflect.ValueOf(out)
+	if rv.Kind() != reflect.Pointer || rv.IsNil() {
+		return fmt.Errorf("the output must be a non-nil pointer, but got %T", out)
+	}
+	d := &decoder{ctx: ctx}
+	return d.decode(value, rv.Elem(), "")
+}
+
+func objectGet(obj *v8go.Object, key string) (*v8go.Value, error) {
+	value, err := obj.Get(key)
+	if err != nil {
+		return nil, fmt.Errorf("unable to access the property \"%s\", %w", key, err)
+	}
+	return value, nil
+}
+
+func objectGetString(obj *v8go.Object, key string) (string, error) {
+	value, err := objectGet(obj, key)
+	if err != nil {
+		return "", err
+	}
+	if value.IsNullOrUndefined() {
+		return "", nil
+	}
+	if !value.IsString() && !value.IsStringObject() {
+		return "", fmt.Errorf("the property \"%s\" is not a string value", key)
+	}
+	return value.String(), nil
+}
+
+func objectGetInt(obj *v8go.Object, key string) (int, error) {
+	value, err := objectGet(obj, key)
+	if err != nil {
+		return 0, err
+	}
+	if value.IsNullOrUndefined() {
+		return 0, nil
+	}
+	if !value.IsNumber() {
+		return 0, fmt.Errorf("the property \"%s\" is not a number value", key)
+	}
+	return int(value.Integer()), nil
+}
+
+func objectGetBool(obj *v8go.Object, key string) (bool, error) {
+	value, err := objectGet(obj, key)
+	if err != nil {
+		return false, err
+	}
+	if value.IsNullOrUndefined() {
+		return false, nil
+	}
+	if !value.IsBoolean() {
+		return false, fmt.Errorf("the property \"%s\" is not a boolean value", key)
+	}
+	return value.Boolean(), nil
+}
+
+func arrayElements(value *v8go.Value) ([]*v8go.Value, error) {
+	if !value.IsArray() {
+		return nil, fmt.Errorf("the input value is not an array")
+	}
+	arr := value.Object()
+	length, err := objectGetInt(arr, "length")
+	if err != nil {
+		return nil, err
+	}
+	elements := make([]*v8go.Value, 0, length)
+	for i := 0; i < length; i++ {
+		element, err := arr.GetIdx(uint32(i))
 		if err != nil {
-			return fmt.Errorf("failed to unmarshal the json string \"%s\", %w", js, err)
+			return nil, fmt.Errorf("unable to access the element %d, %w", i, err)
 		}
+		elements = append(elements, element)
 	}
-	return nil
-}
\ No newline at end of file
+	return elements, nil
+}
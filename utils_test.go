@@ -2,8 +2,11 @@ package v8tsgo
 
 import (
 	"encoding/json"
+	"errors"
 	"math"
+	"math/big"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
@@ -244,6 +247,15 @@ func TestMakeValue(t *testing.T) {
 	tsV, err = dateGetTime(v)
 	panicIfErr(err)
 	test.AssertEqual(t, ts.UnixMilli(), tsV, "")
+	var nilTs *time.Time
+	v, err = MakeValue(ctx, nilTs)
+	panicIfErr(err)
+	test.AssertEqual(t, true, v.IsNull(), "")
+	v, err = MakeValue(ctx, struct{ Expires *time.Time }{})
+	panicIfErr(err)
+	expires, err := v.Object().Get("Expires")
+	panicIfErr(err)
+	test.AssertEqual(t, true, expires.IsNull(), "")
 
 	o := testObject {
 		A: 1,
@@ -288,6 +300,72 @@ func TestMakeValue(t *testing.T) {
 	test.AssertEqual(t, true, isObjectOrArrayEquals(ctx, ar, v), "")
 }
 
+type testEmbedded struct {
+	Shared string `json:"shared"`
+	Inner  int    `json:"inner"`
+}
+
+type testTagged struct {
+	testEmbedded
+	Name    string            `json:"name"`
+	Shared  string            `json:"shared"`
+	Empty   string            `json:"empty,omitempty"`
+	Skipped string            `json:"-"`
+	Dash    string            `json:"-,"`
+	When    time.Time         `json:"when"`
+	Big     *big.Int          `json:"big"`
+	Count   int64             `json:"count"`
+	Err     error             `json:"err"`
+	Labels  map[string]string `json:"labels"`
+	Nil     []int             `json:"nil"`
+	private string
+}
+
+func TestMakeValueReflect(t *testing.T) {
+	ctx := v8go.NewContext()
+	defer ctx.Isolate().Dispose()
+	defer ctx.Close()
+	when := time.UnixMilli(1700000000000)
+	big, _ := new(big.Int).SetString("123456789012345678901234567890", 10)
+	o := testTagged{
+		testEmbedded: testEmbedded{Shared: "embedded", Inner: 3},
+		Name:         "a",
+		Shared:       "outer",
+		Skipped:      "skipped",
+		Dash:         "dash",
+		When:         when,
+		Big:          big,
+		Count:        7,
+		Err:          errors.New("boom"),
+		Labels:       map[string]string{"b": "2", "a": "1"},
+		private:      "private",
+	}
+	v, err := MakeValue(ctx, []any{o})
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("o", v))
+	res, err := ctx.RunScript(`
+		const x = o[0];
+		[
+			Object.keys(x).join(','), x.shared, x.inner, x['-'],
+			x.when instanceof Date, x.when.getTime(),
+			typeof x.big, String(x.big), typeof x.count,
+			x.err instanceof Error, x.err.message,
+			Object.keys(x.labels).join(','), x.nil,
+		].join('|')
+	`, "reflect.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "name,shared,-,when,big,count,err,labels,nil,inner|outer|3|dash|true|1700000000000|"+
+		"bigint|123456789012345678901234567890|number|true|boom|a,b|", res.String(), "")
+
+	// only the integers beyond the safe ones stay bigints
+	v, err = MakeValue(ctx, []int64{-maxSafeInteger, maxSafeInteger + 1})
+	panicIfErr(err)
+	panicIfErr(ctx.Global().Set("ints", v))
+	res, err = ctx.RunScript("ints.map((i) => typeof i).join(',') + '|' + JSON.stringify([ints[0] + 1])", "reflect.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "number,bigint|[-9007199254740990]", res.String(), "")
+}
+
 func TestParseValue(t *testing.T) {
 	ctx := v8go.NewContext()
 	iso := ctx.Isolate()
@@ -309,4 +387,175 @@ func TestParseValue(t *testing.T) {
 	var ts time.Time
 	panicIfErr(ParseValue(ctx, v, &ts))
 	test.AssertEqual(t, now.UnixMilli(), ts.UnixMilli(), "")
-}
\ No newline at end of file
+}
+type testDecoded struct {
+	testEmbedded
+	Name    string            `json:"name"`
+	When    time.Time         `json:"when"`
+	Dates   []*time.Time      `json:"dates"`
+	Labels  map[string]any    `json:"labels"`
+	Counts  map[int]uint8     `json:"counts"`
+	Tags    []string          `json:"tags"`
+	Bytes   []byte            `json:"bytes"`
+	Fixed   [2]int            `json:"fixed"`
+	Next    *testDecoded      `json:"next"`
+	Skipped string            `json:"-"`
+	Any     any               `json:"any"`
+	Raw     json.RawMessage   `json:"raw"`
+	Opt     map[string]string `json:"opt"`
+}
+
+func TestParseValueReflect(t *testing.T) {
+	ctx := v8go.NewContext()
+	defer ctx.Isolate().Dispose()
+	defer ctx.Close()
+	v, err := ctx.RunScript(`({
+		inner: 3, name: 'a', when: new Date(1700000000000), dates: [new Date(1), null],
+		labels: { a: undefined, b: new Date(2) }, counts: new Map([[1, 2], [3, 4]]),
+		tags: new Set(['x', 'y']), bytes: new Uint8Array([1, 2, 255]), fixed: [1],
+		next: { name: 'b', fixed: [5, 6] }, Skipped: 'no', raw: { z: [1] },
+		any: { list: [1, 'two', true, null], big: 10n, set: new Set([1]) },
+	})`, "decode.js")
+	panicIfErr(err)
+	out := testDecoded{Skipped: "kept", Fixed: [2]int{9, 9}}
+	panicIfErr(ParseValue(ctx, v, &out))
+	test.AssertEqual(t, 3, out.Inner, "")
+	test.AssertEqual(t, "a", out.Name, "")
+	test.AssertEqual(t, int64(1700000000000), out.When.UnixMilli(), "")
+	test.MustEqual(t, 2, len(out.Dates), "")
+	test.AssertEqual(t, int64(1), out.Dates[0].UnixMilli(), "")
+	test.AssertEqual(t, true, out.Dates[1] == nil, "")
+	labelA, ok := out.Labels["a"]
+	test.AssertEqual(t, true, ok, "")
+	test.AssertEqual(t, nil, labelA, "")
+	test.AssertEqual(t, int64(2), out.Labels["b"].(time.Time).UnixMilli(), "")
+	test.AssertEqual(t, true, reflect.DeepEqual(map[int]uint8{1: 2, 3: 4}, out.Counts), "")
+	test.AssertEqual(t, true, reflect.DeepEqual([]string{"x", "y"}, out.Tags), "")
+	test.AssertEqual(t, true, reflect.DeepEqual([]byte{1, 2, 255}, out.Bytes), "")
+	test.AssertEqual(t, [2]int{1, 0}, out.Fixed, "")
+	test.MustEqual(t, true, out.Next != nil, "")
+	test.AssertEqual(t, [2]int{5, 6}, out.Next.Fixed, "")
+	test.AssertEqual(t, "kept", out.Skipped, "")
+	test.AssertEqual(t, `{"z":[1]}`, string(out.Raw), "")
+	test.AssertEqual(t, true, out.Opt == nil, "")
+	anyMap := out.Any.(map[string]any)
+	test.AssertEqual(t, true, reflect.DeepEqual([]any{1.0, "two", true, nil}, anyMap["list"]), "")
+	test.AssertEqual(t, int64(10), anyMap["big"].(*big.Int).Int64(), "")
+	test.AssertEqual(t, true, reflect.DeepEqual([]any{1.0}, anyMap["set"]), "")
+
+	v, err = ctx.RunScript(`({ next: { next: { fixed: [1, 'x'] } } })`, "decode-error.js")
+	panicIfErr(err)
+	err = ParseValue(ctx, v, &out)
+	var decodeErr *DecodeError
+	test.MustEqual(t, true, errors.As(err, &decodeErr), "")
+	test.AssertEqual(t, "next.next.fixed[1]", decodeErr.Path, "")
+	test.AssertEqual(t, `field "next.next.fixed[1]": expected number`, err.Error(), "")
+
+	v, err = ctx.RunScript("1.5", "decode-error.js")
+	panicIfErr(err)
+	var i int
+	test.AssertEqual(t, "expected integer, but got 1.5", ParseValue(ctx, v, &i).Error(), "")
+}
+
+func TestParseValueCycle(t *testing.T) {
+	ctx := v8go.NewContext()
+	defer ctx.Isolate().Dispose()
+	defer ctx.Close()
+	v, err := ctx.RunScript("const a = { name: 'a' }; a.next = a; a", "cycle.js")
+	panicIfErr(err)
+	var out any
+	err = ParseValue(ctx, v, &out)
+	var decodeErr *DecodeError
+	test.MustEqual(t, true, errors.As(err, &decodeErr), "")
+	test.AssertEqual(t, true, strings.HasPrefix(decodeErr.Path, "next.next.next."), "")
+	test.AssertEqual(t, "the value is nested too deeply, it may contain a cycle", decodeErr.Message, "")
+
+	var next testDecoded
+	err = ParseValue(ctx, v, &next)
+	test.MustEqual(t, true, errors.As(err, &decodeErr), "")
+	test.AssertEqual(t, "the value is nested too deeply, it may contain a cycle", decodeErr.Message, "")
+}
+
+func TestParseValueUntagged(t *testing.T) {
+	ctx := v8go.NewContext()
+	defer ctx.Isolate().Dispose()
+	defer ctx.Close()
+	v, err := ctx.RunScript("({ name: 'a', count: 2, Tagged: 3, tagged: 4, ID: 5, Id: 6 })", "untagged.js")
+	panicIfErr(err)
+	var out struct {
+		Name   string
+		Count  int
+		Tagged int `json:"tagged"`
+		Id     int
+	}
+	panicIfErr(ParseValue(ctx, v, &out))
+	test.AssertEqual(t, "a", out.Name, "")
+	test.AssertEqual(t, 2, out.Count, "")
+	// the exact match is preferred
+	test.AssertEqual(t, 4, out.Tagged, "")
+	test.AssertEqual(t, 6, out.Id, "")
+
+	v, err = ctx.RunScript("({ ID: 5 })", "untagged.js")
+	panicIfErr(err)
+	panicIfErr(ParseValue(ctx, v, &out))
+	test.AssertEqual(t, 5, out.Id, "")
+}
+
+func TestParseValueBigInt(t *testing.T) {
+	ctx := v8go.NewContext()
+	defer ctx.Isolate().Dispose()
+	defer ctx.Close()
+
+	var u64 uint64 = math.MaxUint64
+	v, err := MakeValue(ctx, u64)
+	panicIfErr(err)
+	var outU64 uint64
+	panicIfErr(ParseValue(ctx, v, &outU64))
+	test.AssertEqual(t, u64, outU64, "")
+
+	var i64 int64 = math.MinInt64
+	v, err = MakeValue(ctx, i64)
+	panicIfErr(err)
+	var outI64 int64
+	panicIfErr(ParseValue(ctx, v, &outI64))
+	test.AssertEqual(t, i64, outI64, "")
+
+	large, _ := new(big.Int).SetString("-123456789012345678901234567890", 10)
+	v, err = MakeValue(ctx, []*big.Int{large})
+	panicIfErr(err)
+	var outBig []*big.Int
+	panicIfErr(ParseValue(ctx, v, &outBig))
+	test.MustEqual(t, 1, len(outBig), "")
+	test.AssertEqual(t, 0, large.Cmp(outBig[0]), "")
+
+	var outStruct struct {
+		ID *big.Int `json:"id"`
+		N  big.Int  `json:"n"`
+	}
+	v, err = ctx.RunScript("({ id: 42, n: '18446744073709551616' })", "bigint.js")
+	panicIfErr(err)
+	panicIfErr(ParseValue(ctx, v, &outStruct))
+	test.AssertEqual(t, int64(42), outStruct.ID.Int64(), "")
+	test.AssertEqual(t, "18446744073709551616", outStruct.N.String(), "")
+
+	v, err = ctx.RunScript("[2n ** 64n]", "bigint.js")
+	panicIfErr(err)
+	var outU64s []uint64
+	test.AssertEqual(t, `field "[0]": the integer 18446744073709551616 overflows uint64`, ParseValue(ctx, v, &outU64s).Error(), "")
+
+	v, err = ctx.RunScript("-1n", "bigint.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "the integer -1 overflows uint64", ParseValue(ctx, v, &outU64).Error(), "")
+
+	v, err = ctx.RunScript("128n", "bigint.js")
+	panicIfErr(err)
+	var i8 int8
+	test.AssertEqual(t, "the integer 128 overflows int8", ParseValue(ctx, v, &i8).Error(), "")
+
+	v, err = ctx.RunScript("2 ** 53", "bigint.js")
+	panicIfErr(err)
+	test.AssertEqual(t, "the number 9.007199254740992e+15 is not a safe integer, use a bigint instead", ParseValue(ctx, v, &outI64).Error(), "")
+	var f64 float64
+	panicIfErr(ParseValue(ctx, v, &f64))
+	test.AssertEqual(t, float64(1<<53), f64, "")
+}
@@ -1,24 +1,107 @@
 package v8tsgo
 
 import (
+	"errors"
 	"fmt"
+	"io/fs"
+	"strings"
 
 	v8 "rogchap.com/v8go"
 )
 
 type V8Utils struct {
 	ctx *v8.Context
+	loop *Loop
 	goUtils *v8.Object
 	fnCreateError *v8.Function
+	fnBytesFromString *v8.Function
+	fnBytesToString *v8.Function
 }
 
+const (
+	goUtilsScript = `var _go_utils = {};
+_go_utils.create_error = (msg, name, code, cause, path, syscall) => {
+	const e = cause === undefined ? new Error(msg) : new Error(msg, { cause });
+	if (name) e.name = name;
+	if (code) e.code = code;
+	if (path) e.path = path;
+	if (syscall) e.syscall = syscall;
+	return e;
+};
+// the instances of the class keep the id of their go value, the fields and the methods call back into go with it
+_go_utils.create_class = (name, construct, get, set, call, fields, methods) => {
+	const id = Symbol(name);
+	const cls = { [name]: class {
+		constructor(...args) {
+			this[id] = construct(...args);
+		}
+	} }[name];
+	for (const f of fields) {
+		Object.defineProperty(cls.prototype, f, {
+			get() { return get(this[id], f); },
+			set(v) { set(this[id], f, v); },
+			enumerable: true,
+			configurable: true,
+		});
+	}
+	for (const m of methods) {
+		Object.defineProperty(cls.prototype, m, {
+			value: { [m](...args) { return call(this[id], m, ...args); } }[m],
+			writable: true,
+			configurable: true,
+		});
+	}
+	if (!methods.includes('toJSON')) {
+		Object.defineProperty(cls.prototype, 'toJSON', {
+			value() { return Object.fromEntries(fields.map((f) => [f, this[f]])); },
+			writable: true,
+			configurable: true,
+		});
+	}
+	const wrap = (i) => {
+		const o = Object.create(cls.prototype);
+		o[id] = i;
+		return o;
+	};
+	const unwrap = (o) => o instanceof cls && id in o ? o[id] : -1;
+	return [cls, wrap, unwrap];
+};
+// v8go can't share the memory of the array buffers, so the bytes cross the boundary as strings of a char per byte
+_go_utils.bytes_from_string = (s) => {
+	const bytes = new Uint8Array(s.length);
+	for (let i = 0; i < s.length; i++) bytes[i] = s.charCodeAt(i);
+	return bytes;
+};
+_go_utils.bytes_to_string = (v) => {
+	let bytes;
+	if (v instanceof ArrayBuffer) {
+		bytes = new Uint8Array(v);
+	} else if (ArrayBuffer.isView(v)) {
+		bytes = new Uint8Array(v.buffer, v.byteOffset, v.byteLength);
+	} else {
+		throw new TypeError('expect an ArrayBuffer or an ArrayBufferView');
+	}
+	let s = '';
+	for (let i = 0; i < bytes.length; i += 0x8000) {
+		s += String.fromCharCode.apply(null, bytes.subarray(i, i + 0x8000));
+	}
+	return s;
+};
+`
+	goUtilsOrigin = "init_go_utils.js"
+)
+
+// NewV8Utils evaluates the go utils init script unless the context already has it, e.g. evaluated with the bundle scripts.
 func NewV8Utils(ctx *v8.Context) (*V8Utils, error) {
 	utils := &V8Utils{
 		ctx: ctx,
+		loop: newLoop(ctx),
 	}
-	_, err := ctx.RunScript("var _go_utils = {}; _go_utils.create_error(msg) = (msg) => new Error(msg);", "init_go_utils.js")
-	if err != nil {
-		return nil, fmt.Errorf("failed to execute the go utils init script, %w", err)
+	if !ctx.Global().Has("_go_utils") {
+		_, err := ctx.RunScript(goUtilsScript, goUtilsOrigin)
+		if err != nil {
+			return nil, fmt.Errorf("failed to execute the go utils init script, %w", err)
+		}
 	}
 	valUtils, err := ctx.Global().Get("_go_utils")
 	if err != nil {
@@ -29,22 +112,97 @@ func NewV8Utils(ctx *v8.Context) (*V8Utils, error) {
 		return nil, fmt.Errorf("unable to cast the go utils value to an object, %w", err)
 	}
 	utils.goUtils = goUtils
-	valCreateError, err := goUtils.Get("create_error")
+	for name, fn := range map[string]**v8.Function{
+		"create_error":      &utils.fnCreateError,
+		"bytes_from_string": &utils.fnBytesFromString,
+		"bytes_to_string":   &utils.fnBytesToString,
+	} {
+		val, err := goUtils.Get(name)
+		if err != nil {
+			return nil, fmt.Errorf("unable to access the %s value, %w", name, err)
+		}
+		*fn, err = val.AsFunction()
+		if err != nil {
+			return nil, fmt.Errorf("unable to cast the %s value to an function, %w", name, err)
+		}
+	}
+	return utils, nil
+}
+
+// NewBytes creates a Uint8Array holding a copy of the bytes.
+func (u *V8Utils) NewBytes(data []byte) (*v8.Value, error) {
+	var sb strings.Builder
+	sb.Grow(len(data))
+	for _, b := range data {
+		sb.WriteRune(rune(b))
+	}
+	s, err := v8.NewValue(u.ctx.Isolate(), sb.String())
 	if err != nil {
-		return nil, fmt.Errorf("unable to access the create_error value, %w", err)
+		return nil, fmt.Errorf("unable to create the bytes, %w", err)
 	}
-	fnCreateError, err := valCreateError.AsFunction()
+	return u.fnBytesFromString.Call(u.goUtils, s)
+}
+
+// Bytes copies the bytes of an ArrayBuffer or an ArrayBufferView, such as a Uint8Array.
+func (u *V8Utils) Bytes(value *v8.Value) ([]byte, error) {
+	s, err := u.fnBytesToString.Call(u.goUtils, value)
 	if err != nil {
-		return nil, fmt.Errorf("unable to cast the create_error value to an function, %w", err)
+		return nil, AsJSException(err)
 	}
-	utils.fnCreateError = fnCreateError
-	return utils, nil
+	str := s.String()
+	data := make([]byte, 0, len(str))
+	for _, r := range str {
+		data = append(data, byte(r))
+	}
+	return data, nil
 }
 
+// Loop returns the event loop the async host methods settle their promises on.
+func (u *V8Utils) Loop() *Loop {
+	return u.loop
+}
+
+// WrapError converts the go error to a js error. The name, the code and the path of a *JSException are kept,
+// the errors of the standard library get the node error codes, such as "ENOENT" for fs.ErrNotExist,
+// a *fs.PathError sets the path and the syscall properties, and the wrapped errors become the cause chain.
 func (u *V8Utils) WrapError(err error) (*v8.Value, error) {
-	valMsg, err := v8.NewValue(u.ctx.Isolate(), err.Error())
-	if err != nil {
-		return nil, fmt.Errorf("unable to create msg value, %w", err)
+	return u.wrapError(err, 0)
+}
+
+func (u *V8Utils) wrapError(err error, depth int) (*v8.Value, error) {
+	iso := u.ctx.Isolate()
+	var message, path, syscall string
+	var pathErr *fs.PathError
+	if ex, ok := err.(*JSException); ok {
+		message, path = ex.Message, ex.Path
+	} else {
+		message = err.Error()
+		if errors.As(err, &pathErr) {
+			path, syscall = pathErr.Path, pathErr.Op
+		}
+	}
+	args := make([]v8.Valuer, 6)
+	for i, s := range []string{message, errorName(err), ErrorCode(err)} {
+		v, e := v8.NewValue(iso, s)
+		if e != nil {
+			return nil, fmt.Errorf("unable to create the error property value, %w", e)
+		}
+		args[i] = v
 	}
-	return u.fnCreateError.Call(u.goUtils, valMsg)
-}
\ No newline at end of file
+	args[3] = v8.Undefined(iso)
+	if cause := errors.Unwrap(err); cause != nil && depth < maxCauseDepth {
+		valCause, e := u.wrapError(cause, depth+1)
+		if e != nil {
+			return nil, e
+		}
+		args[3] = valCause
+	}
+	for i, s := range []string{path, syscall} {
+		v, e := v8.NewValue(iso, s)
+		if e != nil {
+			return nil, fmt.Errorf("unable to create the error property value, %w", e)
+		}
+		args[4+i] = v
+	}
+	return u.fnCreateError.Call(u.goUtils, args...)
+}